@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -10,19 +15,34 @@ import (
 	"syscall"
 	"time"
 
+	"standard-bridge/pkg/keys"
+	"standard-bridge/pkg/metrics"
 	"standard-bridge/pkg/relayer"
+	"standard-bridge/pkg/shared"
 	"standard-bridge/pkg/util"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/urfave/cli/v2"
 	"github.com/urfave/cli/v2/altsrc"
 )
 
 const (
 	defaultHTTPPort  = 8080
+	defaultAdminAddr = "127.0.0.1:8081"
 	defaultConfigDir = "~/.mev-commit-bridge"
 	defaultKeyFile   = "key"
+	defaultDBFile    = "relayer.db"
+)
+
+// keystorePasswordEnvVar holds the keystore passphrase if no password file is set. It has no
+// flag so that the passphrase cannot end up in the config file or the process arguments.
+const keystorePasswordEnvVar = "STANDARD_BRIDGE_RELAYER_KEYSTORE_PASSWORD"
+
+// Signer backends of the relayer.
+const (
+	signerLocal    = "local"
+	signerKeystore = "keystore"
+	signerRemote   = "remote"
 )
 
 var (
@@ -39,6 +59,62 @@ var (
 		Value:   filepath.Join(defaultConfigDir, defaultKeyFile),
 	})
 
+	optionSigner = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "signer",
+		Usage:   "how finalization txs are signed, options are 'local' (priv-key-file), 'keystore' (keystore-file) or 'remote' (remote-signer-url)",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SIGNER"},
+		Value:   signerLocal,
+		Action: func(_ *cli.Context, s string) error {
+			if !slices.Contains([]string{signerLocal, signerKeystore, signerRemote}, s) {
+				return fmt.Errorf("invalid value: -signer=%q", s)
+			}
+			return nil
+		},
+	})
+
+	optionKeystoreFile = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "keystore-file",
+		Usage:   "path to a V3 keystore file holding the relayer key, used with -signer=keystore",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_KEYSTORE_FILE"},
+	})
+
+	optionKeystorePasswordFile = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "keystore-password-file",
+		Usage:   "path to a file holding the passphrase of the keystore file, otherwise it is read from $" + keystorePasswordEnvVar + " or prompted for",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_KEYSTORE_PASSWORD_FILE"},
+	})
+
+	optionRemoteSignerURL = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "remote-signer-url",
+		Usage:   "JSON-RPC URL of a remote signer such as clef or web3signer, used with -signer=remote",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_REMOTE_SIGNER_URL"},
+	})
+
+	optionRemoteSignerMethod = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "remote-signer-method",
+		Usage:   "JSON-RPC method the remote signer signs txs with, options are 'account_signTransaction' (clef) or 'eth_signTransaction' (web3signer)",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_REMOTE_SIGNER_METHOD"},
+		Value:   shared.RemoteSignMethodClef,
+		Action: func(_ *cli.Context, s string) error {
+			if !slices.Contains(shared.RemoteSignMethods, s) {
+				return fmt.Errorf("invalid value: -remote-signer-method=%q", s)
+			}
+			return nil
+		},
+	})
+
+	optionSignerAddress = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "signer-address",
+		Usage:   "address of the account the remote signer signs with",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SIGNER_ADDRESS"},
+		Action: func(_ *cli.Context, s string) error {
+			if !common.IsHexAddress(s) {
+				return fmt.Errorf("invalid value: -signer-address=%q", s)
+			}
+			return nil
+		},
+	})
+
 	optionLogFmt = altsrc.NewStringFlag(&cli.StringFlag{
 		Name:    "log-fmt",
 		Usage:   "log format to use, options are 'text' or 'json'",
@@ -79,17 +155,17 @@ var (
 		},
 	})
 
-	optionL1RPCUrl = altsrc.NewStringFlag(&cli.StringFlag{
+	optionL1RPCUrl = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
 		Name:    "l1-rpc-url",
-		Usage:   "URL for L1 RPC",
+		Usage:   "URL for L1 RPC, may be repeated to fail over between endpoints",
 		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_L1_RPC_URL"},
 	})
 
-	optionSettlementRPCUrl = altsrc.NewStringFlag(&cli.StringFlag{
+	optionSettlementRPCUrl = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
 		Name:    "settlement-rpc-url",
-		Usage:   "URL for settlement RPC",
+		Usage:   "URL for settlement RPC, may be repeated to fail over between endpoints",
 		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SETTLEMENT_RPC_URL"},
-		Value:   "http://localhost:8545",
+		Value:   cli.NewStringSlice("http://localhost:8545"),
 	})
 
 	optionL1ContractAddr = altsrc.NewStringFlag(&cli.StringFlag{
@@ -103,12 +179,160 @@ var (
 		Usage:   "address of the settlement gateway contract",
 		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SETTLEMENT_CONTRACT_ADDR"},
 	})
+
+	optionL1Finality = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "l1-finality",
+		Usage:   "overrides the chain registry finality policy for L1, options are 'finalized', 'safe', 'instant' or 'confirmations:<N>'",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_L1_FINALITY"},
+		Action: func(_ *cli.Context, s string) error {
+			if _, err := shared.ParseFinalityPolicy(s); err != nil {
+				return fmt.Errorf("invalid value: -l1-finality=%q: %w", s, err)
+			}
+			return nil
+		},
+	})
+
+	optionSettlementFinality = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "settlement-finality",
+		Usage:   "overrides the chain registry finality policy for the settlement chain, options are 'finalized', 'safe', 'instant' or 'confirmations:<N>'",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SETTLEMENT_FINALITY"},
+		Action: func(_ *cli.Context, s string) error {
+			if _, err := shared.ParseFinalityPolicy(s); err != nil {
+				return fmt.Errorf("invalid value: -settlement-finality=%q: %w", s, err)
+			}
+			return nil
+		},
+	})
+
+	optionL1GasStrategy = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "l1-gas-strategy",
+		Usage:   "overrides the chain registry gas strategy for L1, options are 'node', 'fee_history' or 'fixed'",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_L1_GAS_STRATEGY"},
+		Action: func(_ *cli.Context, s string) error {
+			if !slices.Contains(shared.GasStrategies, s) {
+				return fmt.Errorf("invalid value: -l1-gas-strategy=%q", s)
+			}
+			return nil
+		},
+	})
+
+	optionSettlementGasStrategy = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "settlement-gas-strategy",
+		Usage:   "overrides the chain registry gas strategy for the settlement chain, options are 'node', 'fee_history' or 'fixed'",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SETTLEMENT_GAS_STRATEGY"},
+		Action: func(_ *cli.Context, s string) error {
+			if !slices.Contains(shared.GasStrategies, s) {
+				return fmt.Errorf("invalid value: -settlement-gas-strategy=%q", s)
+			}
+			return nil
+		},
+	})
+
+	optionPendingTxMode = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "pending-txs",
+		Usage:   "how txs left pending by a previous run are dealt with on start, options are 'adopt', 'cancel' or 'speed-up'",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_PENDING_TXS"},
+		Value:   string(relayer.PendingTxAdopt),
+		Action: func(_ *cli.Context, s string) error {
+			switch relayer.PendingTxMode(s) {
+			case relayer.PendingTxAdopt, relayer.PendingTxCancel, relayer.PendingTxSpeedUp:
+				return nil
+			}
+			return fmt.Errorf("invalid value: -pending-txs=%q", s)
+		},
+	})
+
+	optionL1ToSettlementLimits = altsrc.NewStringFlag(&cli.StringFlag{
+		Name: "l1-to-settlement-limits",
+		Usage: "limits on finalizations of transfers from l1 to the settlement chain, e.g. " +
+			"'max_transfer=10,max_window_value=100,max_window_transfers=500,window=24h' with values in ether, " +
+			"transfers exceeding them are held and the direction paused",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_L1_TO_SETTLEMENT_LIMITS"},
+		Action: func(_ *cli.Context, s string) error {
+			if _, err := relayer.ParseLimits(s); err != nil {
+				return fmt.Errorf("invalid value: -l1-to-settlement-limits=%q: %w", s, err)
+			}
+			return nil
+		},
+	})
+
+	optionSettlementToL1Limits = altsrc.NewStringFlag(&cli.StringFlag{
+		Name: "settlement-to-l1-limits",
+		Usage: "limits on finalizations of transfers from the settlement chain to l1, " +
+			"in the format of l1-to-settlement-limits",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SETTLEMENT_TO_L1_LIMITS"},
+		Action: func(_ *cli.Context, s string) error {
+			if _, err := relayer.ParseLimits(s); err != nil {
+				return fmt.Errorf("invalid value: -settlement-to-l1-limits=%q: %w", s, err)
+			}
+			return nil
+		},
+	})
+
+	optionScreeningDenylist = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name: "screening-denylist",
+		Usage: "path to a file listing one address per line, transfers from or to which are held instead of finalized, " +
+			"may be repeated and is reloaded on changes",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SCREENING_DENYLIST"},
+	})
+
+	optionScreeningAllowlist = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name: "screening-allowlist",
+		Usage: "path to a file listing one address per line, if set transfers are held unless both sender and " +
+			"recipient are listed, may be repeated and is reloaded on changes",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_SCREENING_ALLOWLIST"},
+	})
+
+	optionChainRegistry = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "chain-registry",
+		Usage:   "path to a YAML chain registry, the built-in registry is used if empty",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_CHAIN_REGISTRY"},
+	})
+
+	optionHTTPPort = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:    "http-port",
+		Usage:   "port the relayer serves prometheus metrics (/metrics) and health checks (/healthz, /readyz) on",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_HTTP_PORT"},
+		Value:   defaultHTTPPort,
+		Action: func(_ *cli.Context, port int) error {
+			if port < 1 || port > 65535 {
+				return fmt.Errorf("invalid value: -http-port=%d", port)
+			}
+			return nil
+		},
+	})
+
+	optionAdminAddr = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "admin-addr",
+		Usage:   "address the admin api is served on, which should not be exposed publicly",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_ADMIN_ADDR"},
+		Value:   defaultAdminAddr,
+	})
+
+	optionAdminTokenFile = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "admin-token-file",
+		Usage:   "path to a file holding the bearer token of the admin api, which is disabled if empty",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_ADMIN_TOKEN_FILE"},
+	})
+
+	optionDBPath = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "db-path",
+		Usage:   "path to the relayer database file used to persist listener checkpoints",
+		EnvVars: []string{"STANDARD_BRIDGE_RELAYER_DB_PATH"},
+		Value:   filepath.Join(defaultConfigDir, defaultDBFile),
+	})
 )
 
 func main() {
 	flags := []cli.Flag{
 		optionConfig,
+		optionSigner,
 		optionPrivKeyFile,
+		optionKeystoreFile,
+		optionKeystorePasswordFile,
+		optionRemoteSignerURL,
+		optionRemoteSignerMethod,
+		optionSignerAddress,
 		optionLogFmt,
 		optionLogLevel,
 		optionLogTags,
@@ -116,6 +340,31 @@ func main() {
 		optionSettlementRPCUrl,
 		optionL1ContractAddr,
 		optionSettlementContractAddr,
+		optionChainRegistry,
+		optionL1Finality,
+		optionSettlementFinality,
+		optionL1GasStrategy,
+		optionSettlementGasStrategy,
+		optionPendingTxMode,
+		optionL1ToSettlementLimits,
+		optionSettlementToL1Limits,
+		optionScreeningDenylist,
+		optionScreeningAllowlist,
+		optionHTTPPort,
+		optionAdminAddr,
+		optionAdminTokenFile,
+		optionDBPath,
+	}
+
+	signingFlags := []cli.Flag{
+		optionConfig,
+		optionSigner,
+		optionPrivKeyFile,
+		optionKeystoreFile,
+		optionKeystorePasswordFile,
+		optionRemoteSignerURL,
+		optionRemoteSignerMethod,
+		optionSignerAddress,
 	}
 
 	app := &cli.App{
@@ -127,7 +376,12 @@ func main() {
 			Before: altsrc.InitInputSourceWithContext(flags, altsrc.NewYamlSourceFromFlagFunc(optionConfig.Name)),
 			Flags:  flags,
 			Action: start,
-		}},
+		}, keys.Command(keys.CommandConfig{
+			PasswordEnvVar: keystorePasswordEnvVar,
+			SigningAddress: signingAddress,
+			SigningFlags:   signingFlags,
+			SigningBefore:  altsrc.InitInputSourceWithContext(signingFlags, altsrc.NewYamlSourceFromFlagFunc(optionConfig.Name)),
+		})},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -147,28 +401,116 @@ func start(c *cli.Context) error {
 		return fmt.Errorf("failed to create logger: %w", err)
 	}
 
-	privKeyFile, err := resolveFilePath(c.String(optionPrivKeyFile.Name))
+	signer, closeSigner, err := loadSigner(c)
 	if err != nil {
-		return fmt.Errorf("failed to get private key file path: %w", err)
+		return fmt.Errorf("failed to load signer: %w", err)
 	}
+	defer closeSigner()
 
-	privKey, err := crypto.LoadECDSA(privKeyFile)
+	dbPath, err := resolveFilePath(c.String(optionDBPath.Name))
 	if err != nil {
-		return fmt.Errorf("failed to load private key: %w", err)
+		return fmt.Errorf("failed to get db file path: %w", err)
 	}
 
+	chainRegistryPath := c.String(optionChainRegistry.Name)
+	if chainRegistryPath != "" {
+		if chainRegistryPath, err = resolveFilePath(chainRegistryPath); err != nil {
+			return fmt.Errorf("failed to get chain registry file path: %w", err)
+		}
+	}
+	chains, err := shared.LoadChainRegistry(chainRegistryPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chain registry: %w", err)
+	}
+
+	var l1Finality, settlementFinality *shared.FinalityPolicy
+	if c.IsSet(optionL1Finality.Name) {
+		p, err := shared.ParseFinalityPolicy(c.String(optionL1Finality.Name))
+		if err != nil {
+			return fmt.Errorf("failed to parse l1 finality policy: %w", err)
+		}
+		l1Finality = &p
+	}
+	if c.IsSet(optionSettlementFinality.Name) {
+		p, err := shared.ParseFinalityPolicy(c.String(optionSettlementFinality.Name))
+		if err != nil {
+			return fmt.Errorf("failed to parse settlement finality policy: %w", err)
+		}
+		settlementFinality = &p
+	}
+
+	l1ToSettlementLimits, err := relayer.ParseLimits(c.String(optionL1ToSettlementLimits.Name))
+	if err != nil {
+		return fmt.Errorf("failed to parse l1 to settlement limits: %w", err)
+	}
+	settlementToL1Limits, err := relayer.ParseLimits(c.String(optionSettlementToL1Limits.Name))
+	if err != nil {
+		return fmt.Errorf("failed to parse settlement to l1 limits: %w", err)
+	}
+
+	denylistFiles, err := resolveFilePaths(c.StringSlice(optionScreeningDenylist.Name))
+	if err != nil {
+		return fmt.Errorf("failed to get screening denylist file path: %w", err)
+	}
+	allowlistFiles, err := resolveFilePaths(c.StringSlice(optionScreeningAllowlist.Name))
+	if err != nil {
+		return fmt.Errorf("failed to get screening allowlist file path: %w", err)
+	}
+
+	var adminToken string
+	if c.String(optionAdminTokenFile.Name) != "" {
+		if adminToken, err = readAdminToken(c.String(optionAdminTokenFile.Name)); err != nil {
+			return fmt.Errorf("failed to read admin token: %w", err)
+		}
+	}
+
+	m := metrics.New()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	shutdownHTTP, err := serveHTTP(logger, "http", fmt.Sprintf(":%d", c.Int(optionHTTPPort.Name)), mux)
+	if err != nil {
+		return fmt.Errorf("failed to serve http: %w", err)
+	}
+	defer shutdownHTTP()
+
 	r, err := relayer.NewRelayer(&relayer.Options{
 		Ctx:                    c.Context,
 		Logger:                 logger.With("component", "relayer"),
-		PrivateKey:             privKey,
-		L1RPCUrl:               c.String(optionL1RPCUrl.Name),
-		SettlementRPCUrl:       c.String(optionSettlementRPCUrl.Name),
+		Signer:                 signer,
+		L1RPCUrls:              c.StringSlice(optionL1RPCUrl.Name),
+		SettlementRPCUrls:      c.StringSlice(optionSettlementRPCUrl.Name),
 		L1ContractAddr:         common.HexToAddress(c.String(optionL1ContractAddr.Name)),
 		SettlementContractAddr: common.HexToAddress(c.String(optionSettlementContractAddr.Name)),
+		DBPath:                 dbPath,
+		Chains:                 chains,
+		L1Finality:             l1Finality,
+		SettlementFinality:     settlementFinality,
+		L1GasStrategy:          c.String(optionL1GasStrategy.Name),
+		SettlementGasStrategy:  c.String(optionSettlementGasStrategy.Name),
+		PendingTxMode:          relayer.PendingTxMode(c.String(optionPendingTxMode.Name)),
+		L1ToSettlementLimits:   l1ToSettlementLimits,
+		SettlementToL1Limits:   settlementToL1Limits,
+		DenylistFiles:          denylistFiles,
+		AllowlistFiles:         allowlistFiles,
+		Metrics:                m,
 	})
 	if err != nil {
 		return err
 	}
+	// Probes fail with 404 until the relayer is created.
+	mux.Handle("/healthz", r.LivenessHandler())
+	mux.Handle("/readyz", r.ReadinessHandler())
+
+	if adminToken != "" {
+		shutdownAdmin, err := serveHTTP(logger, "admin api", c.String(optionAdminAddr.Name), r.AdminHandler(adminToken))
+		if err != nil {
+			_ = r.TryCloseAll()
+			return fmt.Errorf("failed to serve admin api: %w", err)
+		}
+		defer shutdownAdmin()
+	} else {
+		logger.Info("admin api disabled, no admin token file set")
+	}
 
 	interruptSigChan := make(chan os.Signal, 1)
 	signal.Notify(interruptSigChan, os.Interrupt, syscall.SIGTERM)
@@ -198,6 +540,117 @@ func start(c *cli.Context) error {
 	return nil
 }
 
+// loadSigner returns the signer selected by the signer option along with a function releasing it.
+func loadSigner(c *cli.Context) (shared.Signer, func(), error) {
+	switch c.String(optionSigner.Name) {
+	case signerKeystore:
+		keystoreFile, err := resolveFilePath(c.String(optionKeystoreFile.Name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get keystore file path: %w", err)
+		}
+		src := keys.PassphraseSource{EnvVar: keystorePasswordEnvVar, Prompt: "Keystore passphrase: "}
+		if c.String(optionKeystorePasswordFile.Name) != "" {
+			if src.File, err = resolveFilePath(c.String(optionKeystorePasswordFile.Name)); err != nil {
+				return nil, nil, fmt.Errorf("failed to get keystore password file path: %w", err)
+			}
+		}
+		passphrase, err := keys.ReadPassphrase(src)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read keystore passphrase: %w", err)
+		}
+		signer, err := shared.LoadKeystoreSigner(keystoreFile, passphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+		return signer, func() {}, nil
+	case signerRemote:
+		if c.String(optionRemoteSignerURL.Name) == "" || !c.IsSet(optionSignerAddress.Name) {
+			return nil, nil, errors.New("remote-signer-url and signer-address are required with -signer=remote")
+		}
+		signer, err := shared.DialRemoteSigner(
+			c.Context,
+			c.String(optionRemoteSignerURL.Name),
+			common.HexToAddress(c.String(optionSignerAddress.Name)),
+			c.String(optionRemoteSignerMethod.Name),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		return signer, signer.Close, nil
+	default:
+		privKeyFile, err := resolveFilePath(c.String(optionPrivKeyFile.Name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get private key file path: %w", err)
+		}
+		signer, err := shared.LoadLocalSigner(privKeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return signer, func() {}, nil
+	}
+}
+
+// signingAddress returns the address of the signer selected by the signer option.
+func signingAddress(c *cli.Context) (common.Address, error) {
+	signer, closeSigner, err := loadSigner(c)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to load signer: %w", err)
+	}
+	defer closeSigner()
+	return signer.Address(), nil
+}
+
+// serveHTTP serves handler on addr until the returned function is called.
+func serveHTTP(logger *slog.Logger, name, addr string, handler http.Handler) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(name+" server failed", "error", err)
+		}
+	}()
+	logger.Info("serving "+name, "addr", listener.Addr().String())
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}, nil
+}
+
+// readAdminToken returns the first line of the admin token file.
+func readAdminToken(path string) (string, error) {
+	path, err := resolveFilePath(path)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token, _, _ := strings.Cut(string(content), "\n")
+	token = strings.TrimSpace(token)
+	if len(token) < 16 {
+		return "", errors.New("admin token must be at least 16 characters long")
+	}
+	return token, nil
+}
+
+// resolveFilePaths resolves every path of paths like resolveFilePath.
+func resolveFilePaths(paths []string) ([]string, error) {
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		p, err := resolveFilePath(path)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
 func resolveFilePath(path string) (string, error) {
 	if path == "" {
 		return "", fmt.Errorf("path is empty")
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 
+	"standard-bridge/pkg/keys"
 	"standard-bridge/pkg/shared"
 	"standard-bridge/pkg/transfer"
 	"standard-bridge/pkg/util"
@@ -23,6 +24,9 @@ import (
 
 var errNoPendingTransactionFound = errors.New("no pending transaction found")
 
+// keystorePasswordEnvVar holds the passphrase of KEYSTORE_FILE if KEYSTORE_PASSWORD_FILE is not set.
+const keystorePasswordEnvVar = "KEYSTORE_PASSWORD"
+
 func main() {
 	app := &cli.App{
 		Name:  "bridge-cli",
@@ -70,6 +74,22 @@ func main() {
 				},
 				Action: bridgeToL1,
 			},
+			{
+				Name:  "speed-up",
+				Usage: "Rebroadcast pending transactions of the signing account with bumped fees",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "chain",
+						Usage:    "Chain of the pending transactions, 'l1' or 'settlement'",
+						Required: true,
+					},
+				},
+				Action: speedUp,
+			},
+			keys.Command(keys.CommandConfig{
+				PasswordEnvVar: keystorePasswordEnvVar,
+				SigningAddress: signingAddress,
+			}),
 		},
 	}
 	if err := app.Run(os.Args); err != nil {
@@ -102,10 +122,10 @@ func bridgeToSettlement(c *cli.Context) error {
 		return err
 	}
 	autoCancel := c.Bool("cancel-pending")
-	ok, err := handlePendingTxes(c.Context, logger.With("component", "l1_eth_client"), config.PrivateKey, config.L1RPCUrl, autoCancel)
+	ok, err := handlePendingTxes(c.Context, logger.With("component", "l1_eth_client"), shared.NewLocalSigner(config.PrivateKey), config.L1RPCUrl, config.L1Chain.Gas, autoCancel)
 	switch {
 	case err == nil && !ok:
-		logger.Info("user chose not to cancel pending transactions, exiting...")
+		logger.Info("user chose not to cancel or speed up pending transactions, exiting...")
 		return nil
 	case errors.Is(err, errNoPendingTransactionFound):
 		// Do nothing.
@@ -122,6 +142,7 @@ func bridgeToSettlement(c *cli.Context) error {
 		config.L1RPCUrl,
 		config.L1ContractAddr,
 		config.SettlementContractAddr,
+		config.Chains,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create transfer to settlement: %w", err)
@@ -147,10 +168,10 @@ func bridgeToL1(c *cli.Context) error {
 		return err
 	}
 	autoCancel := c.Bool("cancel-pending")
-	ok, err := handlePendingTxes(c.Context, logger.With("component", "settlement_eth_client"), config.PrivateKey, config.SettlementRPCUrl, autoCancel)
+	ok, err := handlePendingTxes(c.Context, logger.With("component", "settlement_eth_client"), shared.NewLocalSigner(config.PrivateKey), config.SettlementRPCUrl, config.SettlementChain.Gas, autoCancel)
 	switch {
 	case err == nil && !ok:
-		logger.Info("user chose not to cancel pending transactions, exiting...")
+		logger.Info("user chose not to cancel or speed up pending transactions, exiting...")
 		return nil
 	case errors.Is(err, errNoPendingTransactionFound):
 		// Do nothing.
@@ -167,6 +188,7 @@ func bridgeToL1(c *cli.Context) error {
 		config.L1RPCUrl,
 		config.L1ContractAddr,
 		config.SettlementContractAddr,
+		config.Chains,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create transfer to L1: %w", err)
@@ -178,6 +200,62 @@ func bridgeToL1(c *cli.Context) error {
 	return nil
 }
 
+func speedUp(c *cli.Context) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	logger, err := util.NewLogger(cfg.LogLevel, "text", "", os.Stdout)
+	if err != nil {
+		return err
+	}
+	privKey, err := loadPrivateKey(cfg)
+	if err != nil {
+		return err
+	}
+	var (
+		url   string
+		chain shared.ChainConfig
+	)
+	switch c.String("chain") {
+	case "l1":
+		url = cfg.L1RPCUrl
+		chain, err = cfg.Chains.LookupRole(big.NewInt(int64(cfg.L1ChainID)), shared.L1)
+	case "settlement":
+		url = cfg.SettlementRPCUrl
+		chain, err = cfg.Chains.LookupRole(big.NewInt(int64(cfg.SettlementChainID)), shared.Settlement)
+	default:
+		return fmt.Errorf("chain must be 'l1' or 'settlement', got %q", c.String("chain"))
+	}
+	if err != nil {
+		return err
+	}
+	ethClient, closeClient, err := dialETHClient(c.Context, logger.With("component", c.String("chain")+"_eth_client"), url, chain.Gas)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+	return speedUpPendingTxes(c.Context, ethClient, shared.NewLocalSigner(privKey))
+}
+
+// signingAddress returns the address of the key configured in the environment.
+func signingAddress(_ *cli.Context) (common.Address, error) {
+	cfg := &envConfig{
+		PrivKey:              os.Getenv("PRIVATE_KEY"),
+		PrivKeyFile:          os.Getenv("PRIVATE_KEY_FILE"),
+		KeystoreFile:         os.Getenv("KEYSTORE_FILE"),
+		KeystorePasswordFile: os.Getenv("KEYSTORE_PASSWORD_FILE"),
+	}
+	if cfg.PrivKey == "" && cfg.PrivKeyFile == "" && cfg.KeystoreFile == "" {
+		return common.Address{}, errors.New("one of PRIVATE_KEY, PRIVATE_KEY_FILE and KEYSTORE_FILE is required")
+	}
+	privKey, err := loadPrivateKey(cfg)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(privKey.PublicKey), nil
+}
+
 type preTransferConfig struct {
 	Amount                 *big.Int
 	DestAddress            common.Address
@@ -186,14 +264,40 @@ type preTransferConfig struct {
 	L1RPCUrl               string
 	L1ContractAddr         common.Address
 	SettlementContractAddr common.Address
+	L1Chain                shared.ChainConfig
+	SettlementChain        shared.ChainConfig
+	Chains                 *shared.ChainRegistry
 }
 
-func preTransfer(c *cli.Context, cfg *envConfig) (*preTransferConfig, error) {
-	privKeyTrimmed := strings.TrimPrefix(cfg.PrivKey, "0x")
-	privKey, err := crypto.HexToECDSA(privKeyTrimmed)
+// loadPrivateKey returns the key from the source set in cfg: a keystore file, a plaintext
+// key file or stdin, or the PRIVATE_KEY env var.
+func loadPrivateKey(cfg *envConfig) (*ecdsa.PrivateKey, error) {
+	switch {
+	case cfg.KeystoreFile != "":
+		passphrase, err := keys.ReadPassphrase(keys.PassphraseSource{
+			File:   cfg.KeystorePasswordFile,
+			EnvVar: keystorePasswordEnvVar,
+			Prompt: "Keystore passphrase: ",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore passphrase: %w", err)
+		}
+		return keys.DecryptKeystoreFile(cfg.KeystoreFile, passphrase)
+	case cfg.PrivKeyFile != "":
+		return keys.LoadHexKey(cfg.PrivKeyFile)
+	}
+	privKey, err := keys.ParseHexKey(cfg.PrivKey)
 	if err != nil {
 		return nil, errors.New("failed to load private key")
 	}
+	return privKey, nil
+}
+
+func preTransfer(c *cli.Context, cfg *envConfig) (*preTransferConfig, error) {
+	privKey, err := loadPrivateKey(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	amount := c.Int("amount")
 	if amount <= 0 {
@@ -205,6 +309,15 @@ func preTransfer(c *cli.Context, cfg *envConfig) (*preTransferConfig, error) {
 		return nil, errors.New("dest-addr must be a valid hex address")
 	}
 
+	l1Chain, err := cfg.Chains.LookupRole(big.NewInt(int64(cfg.L1ChainID)), shared.L1)
+	if err != nil {
+		return nil, err
+	}
+	settlementChain, err := cfg.Chains.LookupRole(big.NewInt(int64(cfg.SettlementChainID)), shared.Settlement)
+	if err != nil {
+		return nil, err
+	}
+
 	return &preTransferConfig{
 		Amount:                 big.NewInt(int64(amount)),
 		DestAddress:            common.HexToAddress(destAddr),
@@ -213,11 +326,22 @@ func preTransfer(c *cli.Context, cfg *envConfig) (*preTransferConfig, error) {
 		L1RPCUrl:               cfg.L1RPCUrl,
 		L1ContractAddr:         common.HexToAddress(cfg.L1ContractAddr),
 		SettlementContractAddr: common.HexToAddress(cfg.SettlementContractAddr),
+		L1Chain:                l1Chain,
+		SettlementChain:        settlementChain,
+		Chains:                 cfg.Chains,
 	}, nil
 }
 
 type envConfig struct {
-	PrivKey                string
+	PrivKey string
+	// PrivKeyFile is the path of a plaintext hex key file, or "-" to read the key from stdin.
+	PrivKeyFile string
+	// KeystoreFile is the path of a V3 keystore file, whose passphrase is read from
+	// KeystorePasswordFile, the KEYSTORE_PASSWORD env var or a prompt.
+	KeystoreFile           string
+	KeystorePasswordFile   string
+	ChainRegistryPath      string
+	GasStrategy            string
 	LogLevel               string
 	L1RPCUrl               string
 	SettlementRPCUrl       string
@@ -225,6 +349,9 @@ type envConfig struct {
 	SettlementChainID      int
 	L1ContractAddr         string
 	SettlementContractAddr string
+
+	// Chains is loaded from ChainRegistryPath by checkEnvConfig.
+	Chains *shared.ChainRegistry
 }
 
 func loadConfigFromEnv() (*envConfig, error) {
@@ -240,6 +367,11 @@ func loadConfigFromEnv() (*envConfig, error) {
 	}
 	return &envConfig{
 		PrivKey:                os.Getenv("PRIVATE_KEY"),
+		PrivKeyFile:            os.Getenv("PRIVATE_KEY_FILE"),
+		KeystoreFile:           os.Getenv("KEYSTORE_FILE"),
+		KeystorePasswordFile:   os.Getenv("KEYSTORE_PASSWORD_FILE"),
+		ChainRegistryPath:      os.Getenv("CHAIN_REGISTRY"),
+		GasStrategy:            os.Getenv("GAS_STRATEGY"),
 		LogLevel:               os.Getenv("LOG_LEVEL"),
 		L1RPCUrl:               os.Getenv("L1_RPC_URL"),
 		SettlementRPCUrl:       os.Getenv("SETTLEMENT_RPC_URL"),
@@ -251,8 +383,14 @@ func loadConfigFromEnv() (*envConfig, error) {
 }
 
 func checkEnvConfig(cfg *envConfig) error {
-	if cfg.PrivKey == "" {
-		return fmt.Errorf("private_key is required")
+	keySources := 0
+	for _, src := range []string{cfg.PrivKey, cfg.PrivKeyFile, cfg.KeystoreFile} {
+		if src != "" {
+			keySources++
+		}
+	}
+	if keySources != 1 {
+		return fmt.Errorf("exactly one of private_key, private_key_file and keystore_file is required")
 	}
 	if cfg.LogLevel == "" {
 		cfg.LogLevel = "info"
@@ -260,12 +398,22 @@ func checkEnvConfig(cfg *envConfig) error {
 	if cfg.L1RPCUrl == "" || cfg.SettlementRPCUrl == "" {
 		return fmt.Errorf("both l1_rpc_url and settlement_rpc_url are required")
 	}
-	if cfg.L1ChainID != 39999 && cfg.L1ChainID != 17000 {
-		return fmt.Errorf("l1_chain_id must be 39999 (local l1) or 17000 (Holesky). Only test instances are supported")
+	chains, err := shared.LoadChainRegistry(cfg.ChainRegistryPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chain registry: %w", err)
+	}
+	if cfg.GasStrategy != "" {
+		if chains, err = chains.WithGasStrategy(cfg.GasStrategy); err != nil {
+			return fmt.Errorf("invalid gas_strategy: %w", err)
+		}
 	}
-	if cfg.SettlementChainID != 17864 {
-		return fmt.Errorf("settlement_chain_id must be 17864. Only test chains are supported")
+	if _, err := chains.LookupRole(big.NewInt(int64(cfg.L1ChainID)), shared.L1); err != nil {
+		return fmt.Errorf("invalid l1_chain_id: %w", err)
 	}
+	if _, err := chains.LookupRole(big.NewInt(int64(cfg.SettlementChainID)), shared.Settlement); err != nil {
+		return fmt.Errorf("invalid settlement_chain_id: %w", err)
+	}
+	cfg.Chains = chains
 	if !common.IsHexAddress(cfg.L1ContractAddr) || !common.IsHexAddress(cfg.SettlementContractAddr) {
 		return fmt.Errorf("both l1_contract_addr and settlement_contract_addr must be valid hex addresses")
 	}
@@ -297,17 +445,18 @@ func checkEnvConfig(cfg *envConfig) error {
 func handlePendingTxes(
 	ctx context.Context,
 	logger *slog.Logger,
-	privateKey *ecdsa.PrivateKey,
+	signer shared.Signer,
 	url string,
+	gas shared.GasConfig,
 	autoCancel bool,
 ) (bool, error) {
-	rawClient, err := ethclient.Dial(url)
+	ethClient, closeClient, err := dialETHClient(ctx, logger, url, gas)
 	if err != nil {
-		return false, fmt.Errorf("failed to connect to eth client: %w", err)
+		return false, err
 	}
-	ethClient := shared.NewETHClient(logger, rawClient)
+	defer closeClient()
 
-	exist, err := ethClient.PendingTransactionsExist(ctx, privateKey)
+	exist, err := ethClient.PendingTransactionsExist(ctx, signer.Address())
 	if err != nil {
 		return false, fmt.Errorf("failed to check pending transactions: %w", err)
 	}
@@ -315,22 +464,64 @@ func handlePendingTxes(
 		return false, errNoPendingTransactionFound
 	}
 	if autoCancel {
-		if err := ethClient.CancelPendingTxes(ctx, privateKey); err != nil {
-			return false, fmt.Errorf("fail to cancel pending transaction(s): %w", err)
+		if err := cancelPendingTxes(ctx, ethClient, signer); err != nil {
+			return false, err
 		}
 		return true, nil
 	}
-	fmt.Println("Pending transactions exist for signing account. Do you want to cancel them? (y/n)")
+	fmt.Println("Pending transactions exist for signing account. Do you want to cancel them (y), speed them up (s) or neither (n)?")
 	var response string
 	_, err = fmt.Scanln(&response)
 	if err != nil {
 		return false, fmt.Errorf("failed to read user input: %w", err)
 	}
-	if strings.ToLower(response) == "y" {
-		if err := ethClient.CancelPendingTxes(ctx, privateKey); err != nil {
-			return false, fmt.Errorf("fail to cancel pending transaction(s): %w", err)
+	switch strings.ToLower(response) {
+	case "y":
+		if err := cancelPendingTxes(ctx, ethClient, signer); err != nil {
+			return false, err
+		}
+		return true, nil
+	case "s":
+		if err := speedUpPendingTxes(ctx, ethClient, signer); err != nil {
+			return false, err
 		}
 		return true, nil
 	}
 	return false, nil
 }
+
+// dialETHClient returns an ETHClient for url along with a function closing its connection.
+func dialETHClient(
+	ctx context.Context,
+	logger *slog.Logger,
+	url string,
+	gas shared.GasConfig,
+) (*shared.ETHClient, func(), error) {
+	rawClient, err := shared.DialMultiClient(ctx, logger, []string{url})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to eth client: %w", err)
+	}
+	return shared.NewETHClient(logger, rawClient, gas), rawClient.Close, nil
+}
+
+func speedUpPendingTxes(ctx context.Context, ethClient *shared.ETHClient, signer shared.Signer) error {
+	reports, err := ethClient.SpeedUpPendingTxes(ctx, signer, nil)
+	for _, report := range reports {
+		fmt.Println(report)
+	}
+	if err != nil {
+		return fmt.Errorf("fail to speed up pending transaction(s): %w", err)
+	}
+	return nil
+}
+
+func cancelPendingTxes(ctx context.Context, ethClient *shared.ETHClient, signer shared.Signer) error {
+	reports, err := ethClient.CancelPendingTxes(ctx, signer)
+	for _, report := range reports {
+		fmt.Println(report)
+	}
+	if err != nil {
+		return fmt.Errorf("fail to cancel pending transaction(s): %w", err)
+	}
+	return nil
+}
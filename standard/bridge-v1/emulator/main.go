@@ -60,6 +60,12 @@ func main() {
 	l1ContractAddr := common.HexToAddress(l1ContractAddrString)
 	settlementContractAddr := common.HexToAddress(settlementContractAddrString)
 
+	chains, err := shared.LoadChainRegistry("")
+	if err != nil {
+		logger.Error("failed to load chain registry", "error", err)
+		os.Exit(1)
+	}
+
 	// DD setup
 	ctx := context.WithValue(context.Background(), datadog.ContextAPIKeys, map[string]datadog.APIKey{
 		"apiKeyAuth": {
@@ -84,11 +90,11 @@ func main() {
 		logger.Error("failed to dial settlement rpc", "error", err)
 		os.Exit(1)
 	}
-	if err := shared.NewETHClient(logger.With("component", "l1_eth_client"), l1Client).CancelPendingTxes(ctx, privateKey); err != nil {
+	if _, err := shared.NewETHClient(logger.With("component", "l1_eth_client"), l1Client, shared.GasConfig{}).CancelPendingTxes(ctx, shared.NewLocalSigner(privateKey)); err != nil {
 		logger.Error("failed to cancel pending L1 transactions")
 		os.Exit(1)
 	}
-	if err := shared.NewETHClient(logger.With("component", "settlement_eth_client"), settlementClient).CancelPendingTxes(ctx, privateKey); err != nil {
+	if _, err := shared.NewETHClient(logger.With("component", "settlement_eth_client"), settlementClient, shared.GasConfig{}).CancelPendingTxes(ctx, shared.NewLocalSigner(privateKey)); err != nil {
 		logger.Error("failed to cancel pending settlement transactions")
 		os.Exit(1)
 	}
@@ -118,6 +124,7 @@ func main() {
 			l1RPCUrl,
 			l1ContractAddr,
 			settlementContractAddr,
+			chains,
 		)
 		if err != nil {
 			tags := []string{"environment:bridge_test", "account_addr:" + transferAddressString, "to_chain_id:" + "17864"}
@@ -160,6 +167,7 @@ func main() {
 			l1RPCUrl,
 			l1ContractAddr,
 			settlementContractAddr,
+			chains,
 		)
 		if err != nil {
 			tags := []string{"environment:bridge_test", "account_addr:" + transferAddressString, "to_chain_id:" + "39999"}
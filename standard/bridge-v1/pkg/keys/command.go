@@ -0,0 +1,144 @@
+package keys
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandConfig holds what the keys command needs to know about the binary it is part of.
+type CommandConfig struct {
+	// PasswordEnvVar holds the keystore passphrase if no password file is given.
+	PasswordEnvVar string
+	// SigningAddress returns the address the binary signs with, as configured.
+	SigningAddress func(c *cli.Context) (common.Address, error)
+	// SigningFlags and SigningBefore are the flags SigningAddress reads and how they are loaded.
+	SigningFlags  []cli.Flag
+	SigningBefore cli.BeforeFunc
+}
+
+// Command returns the keys command managing V3 keystore files, with the subcommands new,
+// import, export-address and inspect.
+func Command(cfg CommandConfig) *cli.Command {
+	keystoreDirFlag := &cli.StringFlag{
+		Name:     "keystore-dir",
+		Usage:    "directory the keystore file is written to",
+		Required: true,
+	}
+	passwordFileFlag := &cli.StringFlag{
+		Name:  "password-file",
+		Usage: "path to a file holding the passphrase, otherwise it is read from $" + cfg.PasswordEnvVar + " or prompted for",
+	}
+	passphrase := func(c *cli.Context, confirm bool) (string, error) {
+		return ReadPassphrase(PassphraseSource{
+			File:    c.String(passwordFileFlag.Name),
+			EnvVar:  cfg.PasswordEnvVar,
+			Prompt:  "Keystore passphrase: ",
+			Confirm: confirm,
+		})
+	}
+
+	return &cli.Command{
+		Name:  "keys",
+		Usage: "Manage go-ethereum V3 keystore files",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "new",
+				Usage: "Generate a key and write it to a new keystore file",
+				Flags: []cli.Flag{keystoreDirFlag, passwordFileFlag},
+				Action: func(c *cli.Context) error {
+					pass, err := passphrase(c, true)
+					if err != nil {
+						return err
+					}
+					ks := keystore.NewKeyStore(c.String(keystoreDirFlag.Name), keystore.StandardScryptN, keystore.StandardScryptP)
+					account, err := ks.NewAccount(pass)
+					if err != nil {
+						return fmt.Errorf("failed to create key: %w", err)
+					}
+					fmt.Fprintf(c.App.Writer, "Address:  %s\nKeystore: %s\n", account.Address.Hex(), account.URL.Path)
+					return nil
+				},
+			},
+			{
+				Name:  "import",
+				Usage: "Write a plaintext hex key to a new keystore file",
+				Flags: []cli.Flag{
+					keystoreDirFlag,
+					passwordFileFlag,
+					&cli.StringFlag{
+						Name:     "key-file",
+						Usage:    "path to a file holding the hex key, or '-' to read it from stdin",
+						Required: true,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					key, err := LoadHexKey(c.String("key-file"))
+					if err != nil {
+						return err
+					}
+					pass, err := passphrase(c, true)
+					if err != nil {
+						return err
+					}
+					ks := keystore.NewKeyStore(c.String(keystoreDirFlag.Name), keystore.StandardScryptN, keystore.StandardScryptP)
+					account, err := ks.ImportECDSA(key, pass)
+					if err != nil {
+						return fmt.Errorf("failed to import key: %w", err)
+					}
+					fmt.Fprintf(c.App.Writer, "Address:  %s\nKeystore: %s\n", account.Address.Hex(), account.URL.Path)
+					return nil
+				},
+			},
+			{
+				Name:   "export-address",
+				Usage:  "Print the address this binary signs with, as configured",
+				Flags:  cfg.SigningFlags,
+				Before: cfg.SigningBefore,
+				Action: func(c *cli.Context) error {
+					address, err := cfg.SigningAddress(c)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(c.App.Writer, address.Hex())
+					return nil
+				},
+			},
+			{
+				Name:  "inspect",
+				Usage: "Decrypt a keystore file and print the address of its key",
+				Flags: []cli.Flag{
+					passwordFileFlag,
+					&cli.StringFlag{
+						Name:     "keystore-file",
+						Usage:    "path to the keystore file",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "private",
+						Usage: "also print the private key",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					file := c.String("keystore-file")
+					pass, err := passphrase(c, false)
+					if err != nil {
+						return err
+					}
+					key, err := DecryptKeystoreFile(file, pass)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "Address:  %s\nKeystore: %s\n", crypto.PubkeyToAddress(key.PublicKey).Hex(), file)
+					if c.Bool("private") {
+						fmt.Fprintf(c.App.Writer, "Private key: %x\n", crypto.FromECDSA(key))
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package keys
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/term"
+)
+
+// ErrNoPassphrase is returned when no passphrase source is set and there is no terminal to prompt on.
+var ErrNoPassphrase = errors.New("no passphrase provided and stdin is not a terminal")
+
+// PassphraseSource is where a keystore passphrase is read from, tried in field order.
+type PassphraseSource struct {
+	// File is the path of a file holding the passphrase on its first line.
+	File string
+	// EnvVar is the name of an environment variable holding the passphrase.
+	EnvVar string
+	// Prompt is shown on stderr when the passphrase is read from the terminal.
+	Prompt string
+	// Confirm has the passphrase entered twice when read from the terminal, e.g. for new keys.
+	Confirm bool
+}
+
+// ReadPassphrase returns the passphrase from the first source that is set, prompting on the
+// terminal if neither the file nor the environment variable is.
+func ReadPassphrase(src PassphraseSource) (string, error) {
+	if src.File != "" {
+		data, err := os.ReadFile(src.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		line, _, _ := strings.Cut(string(data), "\n")
+		return strings.TrimRight(line, "\r"), nil
+	}
+	if src.EnvVar != "" {
+		if passphrase, ok := os.LookupEnv(src.EnvVar); ok {
+			return passphrase, nil
+		}
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNoPassphrase
+	}
+	prompt := src.Prompt
+	if prompt == "" {
+		prompt = "Passphrase: "
+	}
+	passphrase, err := promptPassphrase(fd, prompt)
+	if err != nil || !src.Confirm {
+		return passphrase, err
+	}
+	confirmation, err := promptPassphrase(fd, "Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirmation != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func promptPassphrase(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
+
+// DecryptKeystoreFile returns the key stored in file, a V3 keystore file encrypted with passphrase.
+func DecryptKeystoreFile(file, passphrase string) (*ecdsa.PrivateKey, error) {
+	keyJSON, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %w", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore file: %w", err)
+	}
+	return key.PrivateKey, nil
+}
+
+// ParseHexKey returns the key encoded in hex by s, with or without 0x prefix.
+func ParseHexKey(s string) (*ecdsa.PrivateKey, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex private key: %w", err)
+	}
+	return key, nil
+}
+
+// ReadHexKey returns the hex encoded key on the first line of r.
+func ReadHexKey(r io.Reader) (*ecdsa.PrivateKey, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	return ParseHexKey(line)
+}
+
+// LoadHexKey returns the hex encoded key stored in file, or read from stdin if file is "-".
+func LoadHexKey(file string) (*ecdsa.PrivateKey, error) {
+	if file == "-" {
+		return ReadHexKey(os.Stdin)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open private key file: %w", err)
+	}
+	defer f.Close()
+	return ReadHexKey(f)
+}
@@ -0,0 +1,241 @@
+package metrics
+
+import (
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "standard_bridge_relayer"
+
+// latencyBuckets span from a few seconds on instant finality chains to hours
+// of waiting on L1 finality and fee bumps.
+var latencyBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 900, 1800, 3600, 7200}
+
+// Metrics holds the prometheus collectors of the relayer, labeled by chain.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	eventsSeen             *prometheus.CounterVec
+	finalizationsSent      *prometheus.CounterVec
+	finalizationsConfirmed *prometheus.CounterVec
+	finalizationsFailed    *prometheus.CounterVec
+	txsIncluded            *prometheus.CounterVec
+	gasUsed                *prometheus.CounterVec
+	gasSpent               *prometheus.CounterVec
+	feeBumps               *prometheus.CounterVec
+	balance                *prometheus.GaugeVec
+	headBlock              *prometheus.GaugeVec
+	handledBlock           *prometheus.GaugeVec
+	blockLag               *prometheus.GaugeVec
+	relayLatency           *prometheus.HistogramVec
+	inclusionLatency       *prometheus.HistogramVec
+}
+
+// New returns Metrics registered with a new registry, along with the go runtime
+// and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		eventsSeen: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_seen_total",
+			Help:      "Transfer initiated events seen by the listener of the chain.",
+		}, []string{"chain"}),
+		finalizationsSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "finalizations_sent_total",
+			Help:      "Finalizations handed to a worker by the transactor of the chain, including retries.",
+		}, []string{"chain"}),
+		finalizationsConfirmed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "finalizations_confirmed_total",
+			Help:      "Transfers confirmed as finalized on the chain.",
+		}, []string{"chain"}),
+		finalizationsFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "finalizations_failed_total",
+			Help:      "Failed finalization attempts on the chain, by the status the transfer was moved to.",
+		}, []string{"chain", "status"}),
+		txsIncluded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "txs_included_total",
+			Help:      "Txs of the relayer account included on the chain, by receipt status.",
+		}, []string{"chain", "status"}),
+		gasUsed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gas_used_total",
+			Help:      "Gas used by txs of the relayer account included on the chain.",
+		}, []string{"chain"}),
+		gasSpent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gas_spent_wei_total",
+			Help:      "Fees in wei paid for txs of the relayer account included on the chain.",
+		}, []string{"chain"}),
+		feeBumps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fee_bump_attempts_total",
+			Help:      "Attempts to replace a tx not included in time with one paying higher fees.",
+		}, []string{"chain"}),
+		balance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "balance_wei",
+			Help:      "Balance in wei of the relayer account on the chain.",
+		}, []string{"chain"}),
+		headBlock: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "head_block",
+			Help:      "Head block number of the chain, as last polled by the listener.",
+		}, []string{"chain"}),
+		handledBlock: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "handled_block",
+			Help:      "Last block number handled by the listener of the chain.",
+		}, []string{"chain"}),
+		blockLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "block_lag",
+			Help:      "Number of blocks between the head of the chain and the last block handled by its listener.",
+		}, []string{"chain"}),
+		relayLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "relay_latency_seconds",
+			Help:      "Time from a transfer being seen by the listener to its finalization being confirmed on the chain.",
+			Buckets:   latencyBuckets,
+		}, []string{"chain"}),
+		inclusionLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tx_inclusion_latency_seconds",
+			Help:      "Time from a tx of the relayer account being sent to one of its attempts being included on the chain.",
+			Buckets:   latencyBuckets,
+		}, []string{"chain"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.eventsSeen,
+		m.finalizationsSent,
+		m.finalizationsConfirmed,
+		m.finalizationsFailed,
+		m.txsIncluded,
+		m.gasUsed,
+		m.gasSpent,
+		m.feeBumps,
+		m.balance,
+		m.headBlock,
+		m.handledBlock,
+		m.blockLag,
+		m.relayLatency,
+		m.inclusionLatency,
+	)
+	return m
+}
+
+// Handler returns the handler serving the metrics in the prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Chain returns the metrics of chain. It returns nil if m is nil, which is a valid ChainMetrics
+// recording nothing.
+func (m *Metrics) Chain(chain string) *ChainMetrics {
+	if m == nil {
+		return nil
+	}
+	return &ChainMetrics{m: m, chain: chain}
+}
+
+// ChainMetrics records the metrics of a single chain. All methods are no-ops on a nil ChainMetrics,
+// so that components can be instrumented optionally.
+type ChainMetrics struct {
+	m     *Metrics
+	chain string
+}
+
+// EventsSeen records n transfer initiated events seen by the listener.
+func (c *ChainMetrics) EventsSeen(n int) {
+	if c == nil {
+		return
+	}
+	c.m.eventsSeen.WithLabelValues(c.chain).Add(float64(n))
+}
+
+// FinalizationSent records a finalization handed to a worker.
+func (c *ChainMetrics) FinalizationSent() {
+	if c == nil {
+		return
+	}
+	c.m.finalizationsSent.WithLabelValues(c.chain).Inc()
+}
+
+// FinalizationConfirmed records a confirmed finalization of a transfer seen at seenAt.
+func (c *ChainMetrics) FinalizationConfirmed(seenAt time.Time) {
+	if c == nil {
+		return
+	}
+	c.m.finalizationsConfirmed.WithLabelValues(c.chain).Inc()
+	if !seenAt.IsZero() {
+		c.m.relayLatency.WithLabelValues(c.chain).Observe(time.Since(seenAt).Seconds())
+	}
+}
+
+// FinalizationFailed records a failed finalization attempt, after which the transfer is in status.
+func (c *ChainMetrics) FinalizationFailed(status string) {
+	if c == nil {
+		return
+	}
+	c.m.finalizationsFailed.WithLabelValues(c.chain, status).Inc()
+}
+
+// TxIncluded records an included tx, sent at sentAt, which used gasUsed at effectiveGasPrice.
+func (c *ChainMetrics) TxIncluded(succeeded bool, gasUsed uint64, effectiveGasPrice *big.Int, sentAt time.Time) {
+	if c == nil {
+		return
+	}
+	status := "success"
+	if !succeeded {
+		status = "reverted"
+	}
+	c.m.txsIncluded.WithLabelValues(c.chain, status).Inc()
+	c.m.gasUsed.WithLabelValues(c.chain).Add(float64(gasUsed))
+	if effectiveGasPrice != nil {
+		spent, _ := new(big.Float).SetInt(new(big.Int).Mul(effectiveGasPrice, new(big.Int).SetUint64(gasUsed))).Float64()
+		c.m.gasSpent.WithLabelValues(c.chain).Add(spent)
+	}
+	c.m.inclusionLatency.WithLabelValues(c.chain).Observe(time.Since(sentAt).Seconds())
+}
+
+// FeeBump records an attempt to replace a tx with one paying higher fees.
+func (c *ChainMetrics) FeeBump() {
+	if c == nil {
+		return
+	}
+	c.m.feeBumps.WithLabelValues(c.chain).Inc()
+}
+
+// Balance records the balance of the relayer account.
+func (c *ChainMetrics) Balance(wei *big.Int) {
+	if c == nil {
+		return
+	}
+	balance, _ := new(big.Float).SetInt(wei).Float64()
+	c.m.balance.WithLabelValues(c.chain).Set(balance)
+}
+
+// Blocks records the head block of the chain and the last block handled by the listener.
+func (c *ChainMetrics) Blocks(head, handled uint64) {
+	if c == nil {
+		return
+	}
+	c.m.headBlock.WithLabelValues(c.chain).Set(float64(head))
+	c.m.handledBlock.WithLabelValues(c.chain).Set(float64(handled))
+	lag := uint64(0)
+	if head > handled {
+		lag = head - handled
+	}
+	c.m.blockLag.WithLabelValues(c.chain).Set(float64(lag))
+}
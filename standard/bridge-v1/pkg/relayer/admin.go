@@ -0,0 +1,526 @@
+package relayer
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"standard-bridge/pkg/store"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// defaultAdminListLimit and maxAdminListLimit bound the number of transfers listed at once.
+	defaultAdminListLimit = 100
+	maxAdminListLimit     = 1000
+)
+
+// adminListStatuses are the statuses listed by default, i.e. all that may need an operator.
+var adminListStatuses = []store.TransferStatus{
+	store.TransferSeen,
+	store.TransferSubmitted,
+	store.TransferMined,
+	store.TransferFailed,
+	store.TransferDeadLettered,
+	store.TransferQuarantined,
+	store.TransferHeld,
+}
+
+// TransactorState is the runtime state of a transactor shown to operators.
+type TransactorState struct {
+	Direction   string     `json:"direction"`
+	Paused      bool       `json:"paused"`
+	PauseReason string     `json:"pause_reason,omitempty"`
+	PausedAt    *time.Time `json:"paused_at,omitempty"`
+	// InFlight are the source transfer idxs being finalized.
+	InFlight            []string            `json:"in_flight"`
+	Limits              LimitsState         `json:"limits"`
+	MostRecentFinalized MostRecentFinalized `json:"most_recent_finalized"`
+}
+
+// MostRecentFinalized is the cache of the most recent transfer finalized event a transactor
+// found, along with the block range it was found in. Searches for finalized events start there.
+type MostRecentFinalized struct {
+	CounterpartyIdx string  `json:"counterparty_idx,omitempty"`
+	Recipient       string  `json:"recipient,omitempty"`
+	Amount          string  `json:"amount,omitempty"`
+	FromBlock       uint64  `json:"from_block"`
+	ToBlock         *uint64 `json:"to_block,omitempty"`
+}
+
+// ListenerState is the cursor of a listener shown to operators.
+type ListenerState struct {
+	Chain            string  `json:"chain"`
+	ChainID          string  `json:"chain_id"`
+	GatewayAddr      string  `json:"gateway_addr"`
+	LastHandledBlock *uint64 `json:"last_handled_block"`
+}
+
+// State returns the state of the transactor.
+func (t *Transactor) State() TransactorState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := TransactorState{Direction: t.direction, InFlight: []string{}}
+	if t.pause != nil {
+		pausedAt := t.pause.PausedAt
+		state.Paused, state.PauseReason, state.PausedAt = true, t.pause.Reason, &pausedAt
+	}
+	for idx, running := range t.dispatched {
+		if running {
+			state.InFlight = append(state.InFlight, idx)
+		}
+	}
+	if t.preparing != "" {
+		state.InFlight = append(state.InFlight, t.preparing)
+	}
+	state.Limits = t.limiter.state(time.Now())
+	cache := MostRecentFinalized{FromBlock: t.mostRecentFinalized.opts.Start}
+	if end := t.mostRecentFinalized.opts.End; end != nil {
+		toBlock := *end
+		cache.ToBlock = &toBlock
+	}
+	if event := t.mostRecentFinalized.event; event.CounterpartyIdx != nil {
+		cache.CounterpartyIdx = event.CounterpartyIdx.String()
+		cache.Recipient = event.Recipient.Hex()
+		cache.Amount = event.Amount.String()
+	}
+	state.MostRecentFinalized = cache
+	return state
+}
+
+// Pause stops the transactor from dispatching finalizations until Resume is called, also
+// across restarts. Finalizations in flight are completed.
+func (t *Transactor) Pause(ctx context.Context, reason string) error {
+	if err := t.queue.PauseQueue(ctx, t.srcChainID, reason); err != nil {
+		return err
+	}
+	// A queue that was already paused keeps its original pause.
+	pause, paused, err := t.queue.QueuePaused(ctx, t.srcChainID)
+	if err != nil {
+		return err
+	}
+	if !paused {
+		return errors.New("queue was resumed concurrently")
+	}
+	t.mu.Lock()
+	t.pause = &pause
+	t.mu.Unlock()
+	t.logger.Warn("transactor queue paused", "direction", t.direction, "reason", pause.Reason)
+	return nil
+}
+
+// Resume has the transactor dispatch finalizations again.
+func (t *Transactor) Resume(ctx context.Context) error {
+	if err := t.queue.ResumeQueue(ctx, t.srcChainID); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.pause = nil
+	t.mu.Unlock()
+	t.logger.Warn("transactor queue resumed", "direction", t.direction)
+	t.notify()
+	return nil
+}
+
+// Requeue has the transfer with transferIdx finalized again from scratch.
+func (t *Transactor) Requeue(ctx context.Context, transferIdx *big.Int) error {
+	return t.operate(transferIdx, "requeued", func() error {
+		return t.queue.RequeueTransfer(ctx, t.srcChainID, transferIdx)
+	})
+}
+
+// Release has the held transfer with transferIdx finalized regardless of the check that held
+// it, once the queue is resumed. A transfer released from the finalization limits is still
+// screened and counts against the window limits, and one released from screening is still
+// checked against the limits.
+func (t *Transactor) Release(ctx context.Context, transferIdx *big.Int) error {
+	return t.operate(transferIdx, "released", func() error {
+		return t.queue.ReleaseTransfer(ctx, t.srcChainID, transferIdx)
+	})
+}
+
+// Skip takes the transfer with transferIdx out of the queue for good. As the gateway finalizes
+// transfers in order, later transfers are blocked until it is finalized by other means.
+func (t *Transactor) Skip(ctx context.Context, transferIdx *big.Int, reason string) error {
+	return t.operate(transferIdx, "skipped", func() error {
+		return t.queue.MarkTransferSkipped(ctx, t.srcChainID, transferIdx, "skipped by operator: "+reason)
+	})
+}
+
+// Quarantine takes the transfer with transferIdx out of the queue until it is requeued.
+func (t *Transactor) Quarantine(ctx context.Context, transferIdx *big.Int, reason string) error {
+	return t.operate(transferIdx, "quarantined", func() error {
+		return t.queue.MarkTransferQuarantined(ctx, t.srcChainID, transferIdx, fmt.Errorf("quarantined by operator: %s", reason))
+	})
+}
+
+// operate applies op to the transfer with transferIdx unless it is being finalized. The transfer
+// is not dispatched again before the queue is read after op, which mu being held throughout ensures.
+func (t *Transactor) operate(transferIdx *big.Int, action string, op func() error) error {
+	key := transferIdx.String()
+	t.mu.Lock()
+	if t.dispatched[key] || t.preparing == key {
+		t.mu.Unlock()
+		return errTransferInFlight
+	}
+	t.dispatched[key] = false
+	err := op()
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	t.logger.Warn("transfer changed by operator", "action", action, "direction", t.direction, "src_transfer_idx", transferIdx)
+	t.notify()
+	return nil
+}
+
+// notify has the queue read right away.
+func (t *Transactor) notify() {
+	select {
+	case t.wake <- struct{}{}:
+	default: // Already notified
+	}
+}
+
+// State returns the cursor of the listener.
+func (l *Listener) State(ctx context.Context) (ListenerState, error) {
+	state := ListenerState{
+		Chain:       strings.ToLower(l.chain.String()),
+		ChainID:     l.chainID.String(),
+		GatewayAddr: l.gatewayAddr.Hex(),
+	}
+	blockNum, found, err := l.store.LastHandledBlock(ctx, l.chainID, l.gatewayAddr)
+	if err != nil {
+		return ListenerState{}, err
+	}
+	if found {
+		state.LastHandledBlock = &blockNum
+	}
+	return state, nil
+}
+
+// transferView is a transfer as shown to operators.
+type transferView struct {
+	Direction         string    `json:"direction"`
+	SrcChainID        string    `json:"src_chain_id"`
+	TransferIdx       string    `json:"transfer_idx"`
+	Sender            string    `json:"sender"`
+	Recipient         string    `json:"recipient"`
+	Amount            string    `json:"amount"`
+	SrcBlock          uint64    `json:"src_block"`
+	Status            string    `json:"status"`
+	TxHash            string    `json:"tx_hash,omitempty"`
+	Attempts          int       `json:"attempts"`
+	LastError         string    `json:"last_error,omitempty"`
+	HeldBy            string    `json:"held_by,omitempty"`
+	Released          bool      `json:"released,omitempty"`
+	ScreeningReleased bool      `json:"screening_released,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// adminRequest is the body of the admin actions.
+type adminRequest struct {
+	Direction   string `json:"direction"`
+	TransferIdx string `json:"transfer_idx"`
+	Reason      string `json:"reason"`
+}
+
+// AdminHandler returns the handler of the admin API, which requires token as bearer token:
+//
+//	GET  /admin/state                   listener cursors and transactor states
+//	GET  /admin/transfers               transfers, filtered by ?direction=, ?status= (comma-separated) and ?limit=
+//	GET  /admin/screening               screening lists and the most recent screening holds, up to ?limit=
+//	POST /admin/transfers/requeue       {"direction", "transfer_idx"}
+//	POST /admin/transfers/release       {"direction", "transfer_idx"}
+//	POST /admin/transfers/skip          {"direction", "transfer_idx", "reason"}
+//	POST /admin/transfers/quarantine    {"direction", "transfer_idx", "reason"}
+//	POST /admin/directions/pause        {"direction", "reason"}
+//	POST /admin/directions/resume       {"direction"}
+func (r *Relayer) AdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/state", adminGet(r.adminState))
+	mux.HandleFunc("/admin/transfers", adminGet(r.adminTransfers))
+	mux.HandleFunc("/admin/screening", adminGet(r.adminScreening))
+	mux.HandleFunc("/admin/transfers/requeue", r.adminTransferAction(func(ctx context.Context, t *Transactor, idx *big.Int, _ string) error {
+		return t.Requeue(ctx, idx)
+	}))
+	mux.HandleFunc("/admin/transfers/release", r.adminTransferAction(func(ctx context.Context, t *Transactor, idx *big.Int, _ string) error {
+		return t.Release(ctx, idx)
+	}))
+	mux.HandleFunc("/admin/transfers/skip", r.adminTransferAction(func(ctx context.Context, t *Transactor, idx *big.Int, reason string) error {
+		return t.Skip(ctx, idx, reason)
+	}))
+	mux.HandleFunc("/admin/transfers/quarantine", r.adminTransferAction(func(ctx context.Context, t *Transactor, idx *big.Int, reason string) error {
+		return t.Quarantine(ctx, idx, reason)
+	}))
+	mux.HandleFunc("/admin/directions/pause", r.adminDirectionAction(func(ctx context.Context, t *Transactor, reason string) error {
+		return t.Pause(ctx, reason)
+	}))
+	mux.HandleFunc("/admin/directions/resume", r.adminDirectionAction(func(ctx context.Context, t *Transactor, _ string) error {
+		return t.Resume(ctx)
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, errors.New("invalid or missing bearer token"))
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+func (r *Relayer) adminState(req *http.Request) (any, error) {
+	state := struct {
+		Listeners   []ListenerState   `json:"listeners"`
+		Transactors []TransactorState `json:"transactors"`
+	}{}
+	for _, l := range r.listeners {
+		s, err := l.State(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		state.Listeners = append(state.Listeners, s)
+	}
+	for _, t := range r.transactors {
+		state.Transactors = append(state.Transactors, t.State())
+	}
+	return state, nil
+}
+
+func (r *Relayer) adminTransfers(req *http.Request) (any, error) {
+	query := req.URL.Query()
+	transactors := r.transactors
+	if direction := query.Get("direction"); direction != "" {
+		t, err := r.transactor(direction)
+		if err != nil {
+			return nil, err
+		}
+		transactors = []*Transactor{t}
+	}
+	statuses := adminListStatuses
+	if s := query.Get("status"); s != "" {
+		statuses = nil
+		for _, status := range strings.Split(s, ",") {
+			statuses = append(statuses, store.TransferStatus(strings.TrimSpace(status)))
+		}
+	}
+	limit, err := adminListLimit(req)
+	if err != nil {
+		return nil, err
+	}
+
+	views := []transferView{}
+	for _, t := range transactors {
+		transfers, err := r.store.Transfers(req.Context(), t.srcChainID, statuses, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, transfer := range transfers {
+			view := transferView{
+				Direction:         t.direction,
+				SrcChainID:        transfer.SrcChainID.String(),
+				TransferIdx:       transfer.Event.TransferIdx.String(),
+				Sender:            transfer.Event.Sender.Hex(),
+				Recipient:         transfer.Event.Recipient.Hex(),
+				Amount:            transfer.Event.Amount.String(),
+				SrcBlock:          transfer.Event.BlockNumber,
+				Status:            string(transfer.Status),
+				Attempts:          transfer.Attempts,
+				LastError:         transfer.LastError,
+				HeldBy:            string(transfer.HeldBy),
+				Released:          transfer.Released,
+				ScreeningReleased: transfer.ScreeningReleased,
+				CreatedAt:         transfer.CreatedAt,
+				UpdatedAt:         transfer.UpdatedAt,
+			}
+			if transfer.TxHash != (common.Hash{}) {
+				view.TxHash = transfer.TxHash.Hex()
+			}
+			views = append(views, view)
+		}
+	}
+	return views, nil
+}
+
+// screeningHoldView is a screening hold as shown to operators.
+type screeningHoldView struct {
+	SrcChainID  string    `json:"src_chain_id"`
+	TransferIdx string    `json:"transfer_idx"`
+	Sender      string    `json:"sender"`
+	Recipient   string    `json:"recipient"`
+	Amount      string    `json:"amount"`
+	Party       string    `json:"party"`
+	Address     string    `json:"address"`
+	List        string    `json:"list"`
+	File        string    `json:"file,omitempty"`
+	Reason      string    `json:"reason"`
+	HeldAt      time.Time `json:"held_at"`
+}
+
+func (r *Relayer) adminScreening(req *http.Request) (any, error) {
+	if r.screener == nil {
+		return nil, badRequest(errors.New("screening is disabled"))
+	}
+	limit, err := adminListLimit(req)
+	if err != nil {
+		return nil, err
+	}
+	holds, err := r.store.ScreeningHolds(req.Context(), limit)
+	if err != nil {
+		return nil, err
+	}
+	views := []screeningHoldView{}
+	for _, h := range holds {
+		views = append(views, screeningHoldView{
+			SrcChainID:  h.SrcChainID.String(),
+			TransferIdx: h.TransferIdx.String(),
+			Sender:      h.Sender.Hex(),
+			Recipient:   h.Recipient.Hex(),
+			Amount:      h.Amount.String(),
+			Party:       h.Party,
+			Address:     h.Address.Hex(),
+			List:        h.List,
+			File:        h.File,
+			Reason:      h.Reason,
+			HeldAt:      h.HeldAt,
+		})
+	}
+	return struct {
+		Lists ScreeningState      `json:"lists"`
+		Holds []screeningHoldView `json:"holds"`
+	}{r.screener.State(), views}, nil
+}
+
+// adminListLimit returns the limit query parameter of req.
+func adminListLimit(req *http.Request) (int, error) {
+	s := req.URL.Query().Get("limit")
+	if s == "" {
+		return defaultAdminListLimit, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > maxAdminListLimit {
+		return 0, badRequest(fmt.Errorf("invalid limit %q, must be in [1, %d]", s, maxAdminListLimit))
+	}
+	return limit, nil
+}
+
+func (r *Relayer) adminTransferAction(
+	action func(ctx context.Context, t *Transactor, transferIdx *big.Int, reason string) error,
+) http.HandlerFunc {
+	return adminPost(func(req *http.Request, body adminRequest) (any, error) {
+		t, err := r.transactor(body.Direction)
+		if err != nil {
+			return nil, err
+		}
+		transferIdx, ok := new(big.Int).SetString(body.TransferIdx, 10)
+		if !ok {
+			return nil, badRequest(fmt.Errorf("invalid transfer idx %q", body.TransferIdx))
+		}
+		// Fails with sql.ErrNoRows for unknown transfers, before any state is changed.
+		if _, err := r.store.GetTransfer(req.Context(), t.srcChainID, transferIdx); err != nil {
+			return nil, err
+		}
+		if err := action(req.Context(), t, transferIdx, body.Reason); err != nil {
+			return nil, err
+		}
+		transfer, err := r.store.GetTransfer(req.Context(), t.srcChainID, transferIdx)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"direction": t.direction, "transfer_idx": transferIdx.String(), "status": string(transfer.Status)}, nil
+	})
+}
+
+func (r *Relayer) adminDirectionAction(action func(ctx context.Context, t *Transactor, reason string) error) http.HandlerFunc {
+	return adminPost(func(req *http.Request, body adminRequest) (any, error) {
+		t, err := r.transactor(body.Direction)
+		if err != nil {
+			return nil, err
+		}
+		if err := action(req.Context(), t, body.Reason); err != nil {
+			return nil, err
+		}
+		return t.State(), nil
+	})
+}
+
+// transactor returns the transactor finalizing the transfers of direction.
+func (r *Relayer) transactor(direction string) (*Transactor, error) {
+	var directions []string
+	for _, t := range r.transactors {
+		if t.direction == direction {
+			return t, nil
+		}
+		directions = append(directions, t.direction)
+	}
+	return nil, badRequest(fmt.Errorf("unknown direction %q, expected one of %s", direction, strings.Join(directions, ", ")))
+}
+
+// errBadRequest marks errors caused by the request rather than the relayer.
+var errBadRequest = errors.New("bad request")
+
+func badRequest(err error) error {
+	return fmt.Errorf("%w: %w", errBadRequest, err)
+}
+
+func adminGet(handle func(req *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+			return
+		}
+		result, err := handle(req)
+		writeAdminResult(w, result, err)
+	}
+}
+
+func adminPost(handle func(req *http.Request, body adminRequest) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+			return
+		}
+		var body adminRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, req.Body, 1<<16))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&body); err != nil {
+			writeAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		result, err := handle(req, body)
+		writeAdminResult(w, result, err)
+	}
+}
+
+func writeAdminResult(w http.ResponseWriter, result any, err error) {
+	switch {
+	case errors.Is(err, errBadRequest):
+		writeAdminError(w, http.StatusBadRequest, err)
+	case errors.Is(err, sql.ErrNoRows):
+		writeAdminError(w, http.StatusNotFound, err)
+	case errors.Is(err, store.ErrInvalidTransition), errors.Is(err, errTransferInFlight):
+		writeAdminError(w, http.StatusConflict, err)
+	case err != nil:
+		writeAdminError(w, http.StatusInternalServerError, err)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,217 @@
+package relayer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"standard-bridge/pkg/shared"
+)
+
+// stallPolls is the number of poll intervals without a successful poll after which
+// a listener or transactor is considered stalled.
+const stallPolls = 5
+
+// HealthStatus is the status of a component or of the rpc endpoints of a chain.
+type HealthStatus string
+
+const (
+	HealthOK HealthStatus = "ok"
+	// HealthStarting means the component has not completed its startup, e.g. the initial sync.
+	HealthStarting HealthStatus = "starting"
+	// HealthStalled means the component is running, but did not poll successfully in a while.
+	HealthStalled HealthStatus = "stalled"
+	// HealthStopped means the goroutine of the component has exited.
+	HealthStopped HealthStatus = "stopped"
+	// HealthUnreachable means no rpc endpoint of the chain responded to its last probe.
+	HealthUnreachable HealthStatus = "unreachable"
+)
+
+// ComponentHealth is the health of a listener or transactor.
+type ComponentHealth struct {
+	Name               string       `json:"name"`
+	Status             HealthStatus `json:"status"`
+	LastSuccessfulPoll *time.Time   `json:"last_successful_poll,omitempty"`
+	LastError          string       `json:"last_error,omitempty"`
+	LastErrorAt        *time.Time   `json:"last_error_at,omitempty"`
+}
+
+// EndpointHealth is the health of a single rpc endpoint.
+type EndpointHealth struct {
+	URL       string     `json:"url"`
+	Reachable bool       `json:"reachable"`
+	Healthy   bool       `json:"healthy"`
+	Head      uint64     `json:"head"`
+	HeadLag   uint64     `json:"head_lag"`
+	Latency   string     `json:"latency"`
+	ErrorRate float64    `json:"error_rate"`
+	ProbedAt  *time.Time `json:"probed_at,omitempty"`
+	ProbeErr  string     `json:"probe_error,omitempty"`
+}
+
+// RPCHealth is the health of the rpc endpoints of a chain, which is reachable if any endpoint is.
+type RPCHealth struct {
+	Chain     string           `json:"chain"`
+	Status    HealthStatus     `json:"status"`
+	Endpoints []EndpointHealth `json:"endpoints"`
+}
+
+// HealthReport is the health of the relayer. It is healthy unless a component stopped or stalled,
+// which takes a restart to recover from, and ready once additionally all components started and
+// the rpc endpoints of both chains are reachable.
+type HealthReport struct {
+	Healthy    bool              `json:"healthy"`
+	Ready      bool              `json:"ready"`
+	Components []ComponentHealth `json:"components"`
+	RPC        []RPCHealth       `json:"rpc"`
+}
+
+// healthTracker records the progress of the loop of a listener or transactor.
+type healthTracker struct {
+	name string
+	// stallAfter is how long the component may go without a successful poll.
+	stallAfter time.Duration
+
+	mu          sync.Mutex
+	started     bool
+	stopped     bool
+	lastSuccess time.Time
+	lastErr     error
+	lastErrAt   time.Time
+}
+
+func newHealthTracker(name string, stallAfter time.Duration) *healthTracker {
+	return &healthTracker{name: name, stallAfter: stallAfter}
+}
+
+// start marks the startup of the component as completed, which counts as a successful poll.
+func (h *healthTracker) start() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started = true
+	h.lastSuccess = time.Now()
+}
+
+func (h *healthTracker) succeeded() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSuccess = time.Now()
+}
+
+func (h *healthTracker) failed(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastErr, h.lastErrAt = err, time.Now()
+}
+
+func (h *healthTracker) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopped = true
+}
+
+// health returns the health of the component. A component that is busy, e.g. waiting on
+// finalizations in flight, is not considered stalled.
+func (h *healthTracker) health(busy bool) ComponentHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c := ComponentHealth{Name: h.name, Status: HealthOK}
+	switch {
+	case h.stopped:
+		c.Status = HealthStopped
+	case !h.started:
+		c.Status = HealthStarting
+	case !busy && time.Since(h.lastSuccess) > h.stallAfter:
+		c.Status = HealthStalled
+	}
+	if !h.lastSuccess.IsZero() {
+		lastSuccess := h.lastSuccess
+		c.LastSuccessfulPoll = &lastSuccess
+	}
+	if h.lastErr != nil {
+		lastErrAt := h.lastErrAt
+		c.LastError, c.LastErrorAt = h.lastErr.Error(), &lastErrAt
+	}
+	return c
+}
+
+// rpcHealth returns the health of the rpc endpoints of client, serving chain.
+func rpcHealth(chain string, client *shared.MultiClient) RPCHealth {
+	h := RPCHealth{Chain: chain, Status: HealthUnreachable}
+	for _, e := range client.Health() {
+		endpoint := EndpointHealth{
+			URL:       e.URL,
+			Reachable: e.Reachable,
+			Healthy:   e.Healthy,
+			Head:      e.Head,
+			HeadLag:   e.HeadLag,
+			Latency:   e.Latency.String(),
+			ErrorRate: e.ErrorRate,
+		}
+		if !e.ProbedAt.IsZero() {
+			probedAt := e.ProbedAt
+			endpoint.ProbedAt = &probedAt
+		}
+		if e.ProbeErr != nil {
+			endpoint.ProbeErr = e.ProbeErr.Error()
+		}
+		if e.Reachable {
+			h.Status = HealthOK
+		}
+		h.Endpoints = append(h.Endpoints, endpoint)
+	}
+	return h
+}
+
+// Health returns the health of the listeners, transactors and rpc endpoints of the relayer.
+func (r *Relayer) Health() HealthReport {
+	report := HealthReport{Healthy: true, Ready: true}
+	for _, l := range r.listeners {
+		report.Components = append(report.Components, l.Health())
+	}
+	for _, t := range r.transactors {
+		report.Components = append(report.Components, t.Health())
+	}
+	for _, c := range report.Components {
+		switch c.Status {
+		case HealthStopped, HealthStalled:
+			report.Healthy, report.Ready = false, false
+		case HealthStarting:
+			report.Ready = false
+		}
+	}
+	report.RPC = []RPCHealth{rpcHealth("l1", r.l1Client), rpcHealth("settlement", r.settlementClient)}
+	for _, h := range report.RPC {
+		if h.Status != HealthOK {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// LivenessHandler serves the health report with status 200 if the relayer is healthy, 503 otherwise.
+func (r *Relayer) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		report := r.Health()
+		writeHealthReport(w, report, report.Healthy)
+	})
+}
+
+// ReadinessHandler serves the health report with status 200 if the relayer is ready, 503 otherwise.
+func (r *Relayer) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		report := r.Health()
+		writeHealthReport(w, report, report.Ready)
+	})
+}
+
+func writeHealthReport(w http.ResponseWriter, report HealthReport, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,176 @@
+package relayer
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// defaultLimitWindow is the rolling window of the window limits if none is configured.
+const defaultLimitWindow = 24 * time.Hour
+
+// Limits caps the finalizations a transactor dispatches. Zero values disable a limit.
+type Limits struct {
+	// MaxTransferValue is the maximum amount of a single transfer, in wei.
+	MaxTransferValue *big.Int
+	// Window is the rolling window MaxWindowValue and MaxWindowTransfers apply to.
+	Window time.Duration
+	// MaxWindowValue is the maximum amount of all transfers finalized within Window, in wei.
+	MaxWindowValue *big.Int
+	// MaxWindowTransfers is the maximum number of transfers finalized within Window.
+	MaxWindowTransfers int
+}
+
+// ParseLimits parses comma-separated limits, e.g. "max_transfer=10,max_window_value=100,
+// max_window_transfers=500,window=24h". Values are in ether. The empty string disables all limits.
+func ParseLimits(s string) (Limits, error) {
+	var l Limits
+	if strings.TrimSpace(s) == "" {
+		return l, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			return Limits{}, fmt.Errorf("invalid limit %q, expected key=value", kv)
+		}
+		var err error
+		switch key {
+		case "max_transfer":
+			l.MaxTransferValue, err = parseEther(value)
+		case "max_window_value":
+			l.MaxWindowValue, err = parseEther(value)
+		case "max_window_transfers":
+			l.MaxWindowTransfers, err = strconv.Atoi(value)
+			if err == nil && l.MaxWindowTransfers < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "window":
+			l.Window, err = time.ParseDuration(value)
+			if err == nil && l.Window <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		default:
+			return Limits{}, fmt.Errorf("unknown limit %q", key)
+		}
+		if err != nil {
+			return Limits{}, fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+	}
+	if l.Window == 0 {
+		l.Window = defaultLimitWindow
+	}
+	return l, nil
+}
+
+// parseEther parses a decimal amount of ether into wei.
+func parseEther(s string) (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() < 0 {
+		return nil, fmt.Errorf("not a non-negative decimal amount")
+	}
+	r.Mul(r, new(big.Rat).SetInt64(params.Ether))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("more precise than wei")
+	}
+	return r.Num(), nil
+}
+
+// windowed reports whether any window limit is set.
+func (l Limits) windowed() bool {
+	return l.MaxWindowValue != nil || l.MaxWindowTransfers > 0
+}
+
+// LimitsState is the configuration and current usage of the limits of a transactor.
+type LimitsState struct {
+	MaxTransferValue   string `json:"max_transfer_value,omitempty"`
+	Window             string `json:"window,omitempty"`
+	MaxWindowValue     string `json:"max_window_value,omitempty"`
+	MaxWindowTransfers int    `json:"max_window_transfers,omitempty"`
+	WindowValue        string `json:"window_value"`
+	WindowTransfers    int    `json:"window_transfers"`
+}
+
+// submission is a finalization counted against the window limits.
+type submission struct {
+	at     time.Time
+	amount *big.Int
+}
+
+// limiter enforces Limits on the finalizations of a transactor. It is not safe for concurrent use.
+type limiter struct {
+	limits Limits
+	// window holds the submissions within the rolling window, oldest first.
+	window []submission
+}
+
+func newLimiter(limits Limits) *limiter {
+	return &limiter{limits: limits}
+}
+
+// check returns an error describing the limit a finalization of amount would exceed, if any.
+func (l *limiter) check(now time.Time, amount *big.Int) error {
+	if limit := l.limits.MaxTransferValue; limit != nil && amount.Cmp(limit) > 0 {
+		return fmt.Errorf("transfer value of %s wei exceeds max transfer value of %s wei", amount, limit)
+	}
+	if !l.limits.windowed() {
+		return nil
+	}
+	l.prune(now)
+	if limit := l.limits.MaxWindowTransfers; limit > 0 && len(l.window)+1 > limit {
+		return fmt.Errorf("transfer would exceed max of %d transfers within %s", limit, l.limits.Window)
+	}
+	if limit := l.limits.MaxWindowValue; limit != nil {
+		if value := new(big.Int).Add(l.value(), amount); value.Cmp(limit) > 0 {
+			return fmt.Errorf("transfer would raise value within %s to %s wei, exceeding max window value of %s wei",
+				l.limits.Window, value, limit)
+		}
+	}
+	return nil
+}
+
+// record counts a finalization of amount sent at at against the window limits.
+func (l *limiter) record(at time.Time, amount *big.Int) {
+	if l.limits.windowed() {
+		l.window = append(l.window, submission{at: at, amount: amount})
+	}
+}
+
+func (l *limiter) prune(now time.Time) {
+	start := now.Add(-l.limits.Window)
+	i := 0
+	for i < len(l.window) && l.window[i].at.Before(start) {
+		i++
+	}
+	l.window = l.window[i:]
+}
+
+func (l *limiter) value() *big.Int {
+	value := new(big.Int)
+	for _, s := range l.window {
+		value.Add(value, s.amount)
+	}
+	return value
+}
+
+func (l *limiter) state(now time.Time) LimitsState {
+	l.prune(now)
+	s := LimitsState{
+		MaxWindowTransfers: l.limits.MaxWindowTransfers,
+		WindowValue:        l.value().String(),
+		WindowTransfers:    len(l.window),
+	}
+	if l.limits.MaxTransferValue != nil {
+		s.MaxTransferValue = l.limits.MaxTransferValue.String()
+	}
+	if l.limits.windowed() {
+		s.Window = l.limits.Window.String()
+	}
+	if l.limits.MaxWindowValue != nil {
+		s.MaxWindowValue = l.limits.MaxWindowValue.String()
+	}
+	return s
+}
@@ -0,0 +1,162 @@
+package relayer
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.Ether))
+}
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Limits
+		wantErr bool
+	}{
+		{
+			name: "empty disables all limits",
+			in:   "",
+			want: Limits{},
+		},
+		{
+			name: "all limits",
+			in:   "max_transfer=10, max_window_value=100,max_window_transfers=500,window=1h",
+			want: Limits{
+				MaxTransferValue:   ether(10),
+				Window:             time.Hour,
+				MaxWindowValue:     ether(100),
+				MaxWindowTransfers: 500,
+			},
+		},
+		{
+			name: "default window",
+			in:   "max_window_transfers=5",
+			want: Limits{Window: defaultLimitWindow, MaxWindowTransfers: 5},
+		},
+		{
+			name: "fractional ether",
+			in:   "max_transfer=0.5",
+			want: Limits{MaxTransferValue: new(big.Int).Div(ether(1), big.NewInt(2)), Window: defaultLimitWindow},
+		},
+		{name: "unknown key", in: "max_foo=1", wantErr: true},
+		{name: "missing value", in: "max_transfer", wantErr: true},
+		{name: "negative amount", in: "max_transfer=-1", wantErr: true},
+		{name: "below wei", in: "max_transfer=0.0000000000000000001", wantErr: true},
+		{name: "negative transfers", in: "max_window_transfers=-1", wantErr: true},
+		{name: "zero window", in: "window=0s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimits(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseLimits(%q) = %+v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLimits(%q) failed: %v", tt.in, err)
+			}
+			if !bigEqual(got.MaxTransferValue, tt.want.MaxTransferValue) ||
+				!bigEqual(got.MaxWindowValue, tt.want.MaxWindowValue) ||
+				got.Window != tt.want.Window ||
+				got.MaxWindowTransfers != tt.want.MaxWindowTransfers {
+				t.Fatalf("ParseLimits(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func bigEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+func TestLimiterCheck(t *testing.T) {
+	now := time.Now()
+	limits := Limits{
+		MaxTransferValue:   ether(10),
+		Window:             time.Hour,
+		MaxWindowValue:     ether(20),
+		MaxWindowTransfers: 3,
+	}
+	type record struct {
+		ago    time.Duration
+		amount *big.Int
+	}
+	tests := []struct {
+		name     string
+		limits   Limits
+		recorded []record
+		amount   *big.Int
+		wantErr  bool
+	}{
+		{
+			name:   "no limits",
+			amount: ether(1000),
+		},
+		{
+			name:   "within all limits",
+			limits: limits,
+			amount: ether(10),
+		},
+		{
+			name:    "above max transfer value",
+			limits:  limits,
+			amount:  new(big.Int).Add(ether(10), big.NewInt(1)),
+			wantErr: true,
+		},
+		{
+			name:     "window value reached",
+			limits:   limits,
+			recorded: []record{{time.Minute, ether(10)}, {time.Second, ether(5)}},
+			amount:   ether(6),
+			wantErr:  true,
+		},
+		{
+			name:     "window value exactly reached",
+			limits:   limits,
+			recorded: []record{{time.Minute, ether(10)}, {time.Second, ether(5)}},
+			amount:   ether(5),
+		},
+		{
+			name:     "window transfers reached",
+			limits:   limits,
+			recorded: []record{{3 * time.Minute, ether(1)}, {2 * time.Minute, ether(1)}, {time.Minute, ether(1)}},
+			amount:   ether(1),
+			wantErr:  true,
+		},
+		{
+			name:     "submissions older than the window are pruned",
+			limits:   limits,
+			recorded: []record{{2 * time.Hour, ether(10)}, {90 * time.Minute, ether(10)}, {time.Minute, ether(5)}},
+			amount:   ether(10),
+		},
+		{
+			name:     "max transfer value only records nothing",
+			limits:   Limits{MaxTransferValue: ether(10), Window: time.Hour},
+			recorded: []record{{time.Minute, ether(10)}, {time.Minute, ether(10)}},
+			amount:   ether(10),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(tt.limits)
+			for _, r := range tt.recorded {
+				l.record(now.Add(-r.ago), r.amount)
+			}
+			err := l.check(now, tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check(%s) = %v, want error: %t", tt.amount, err, tt.wantErr)
+			}
+		})
+	}
+}
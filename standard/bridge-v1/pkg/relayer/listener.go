@@ -2,152 +2,466 @@ package relayer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/big"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"standard-bridge/pkg/metrics"
 	"standard-bridge/pkg/shared"
+	"standard-bridge/pkg/store"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
+const (
+	// subscribedPollInterval is how often a listener with an active event subscription
+	// polls anyway, as a safety net for events the subscription missed.
+	subscribedPollInterval = time.Minute
+	// minResubscribeDelay and maxResubscribeDelay bound the backoff between attempts
+	// to (re)subscribe to transfer initiated events.
+	minResubscribeDelay = time.Second
+	maxResubscribeDelay = 5 * time.Minute
+)
+
+// ListenerStore persists the transfer initiated events seen by a listener together with
+// the last block it handled and its hash, keyed by chain id and gateway address.
+type ListenerStore interface {
+	LastHandledBlock(ctx context.Context, chainID *big.Int, gatewayAddr common.Address) (uint64, bool, error)
+	SaveHandledEvents(
+		ctx context.Context,
+		chainID *big.Int,
+		gatewayAddr common.Address,
+		events []shared.TransferInitiatedEvent,
+		blockNum uint64,
+		blockHash common.Hash,
+	) error
+	HandledBlocks(
+		ctx context.Context,
+		chainID *big.Int,
+		gatewayAddr common.Address,
+		blockNum uint64,
+	) ([]store.HandledBlock, error)
+	RollbackListener(ctx context.Context, reorg store.Reorg) (retracted, inFlight []store.Transfer, err error)
+}
+
 type Listener struct {
 	logger          *slog.Logger
-	rawClient       *ethclient.Client
+	rawClient       shared.Backend
+	gatewayAddr     common.Address
 	gatewayFilterer shared.GatewayFilterer
+	store           ListenerStore
+	chainCfg        shared.ChainConfig
 	sync            bool
+	chainID         *big.Int
 	chain           shared.Chain
-	DoneChan        chan struct{}
-	EventChan       chan shared.TransferInitiatedEvent
+	metrics         *metrics.ChainMetrics
+	health          *healthTracker
+	// subscribed is set while events are pushed by a subscription,
+	// otherwise the listener polls on every tick.
+	subscribed atomic.Bool
+	DoneChan   chan struct{}
+	// NotifyChan is signaled whenever new events were persisted to the store.
+	NotifyChan chan struct{}
 }
 
 func NewListener(
 	logger *slog.Logger,
-	client *ethclient.Client,
+	client shared.Backend,
+	gatewayAddr common.Address,
 	gatewayFilterer shared.GatewayFilterer,
+	store ListenerStore,
+	chainCfg shared.ChainConfig,
 	sync bool,
+	chainMetrics *metrics.ChainMetrics,
 ) *Listener {
 	return &Listener{
 		logger:          logger,
 		rawClient:       client,
+		gatewayAddr:     gatewayAddr,
 		gatewayFilterer: gatewayFilterer,
+		store:           store,
+		chainCfg:        chainCfg,
 		sync:            true,
+		metrics:         chainMetrics,
+		health: newHealthTracker(
+			strings.ToLower(chainCfg.Role.String())+"_listener",
+			stallPolls*max(chainCfg.PollInterval, subscribedPollInterval),
+		),
 	}
 }
 
 func (l *Listener) Start(ctx context.Context) (
-	<-chan struct{}, <-chan shared.TransferInitiatedEvent, error,
+	<-chan struct{}, <-chan struct{}, error,
 ) {
 	chainID, err := l.rawClient.ChainID(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get chain id: %w", err)
 	}
-	switch chainID.String() {
-	case "39999":
-		l.logger.Info("starting listener for local_l1")
-		l.chain = shared.L1
-	case "17000":
-		l.logger.Info("starting listener for Holesky L1")
-		l.chain = shared.L1
-	case "17864":
-		l.logger.Info("starting listener for mev-commit chain (settlement)")
-		l.chain = shared.Settlement
-	default:
-		return nil, nil, fmt.Errorf("unsupported chain id: %s", chainID.String())
+	if chainID.Uint64() != l.chainCfg.ChainID {
+		return nil, nil, fmt.Errorf("chain id mismatch, expected %d, obtained %s", l.chainCfg.ChainID, chainID)
+	}
+	l.chainID = chainID
+	l.chain = l.chainCfg.Role
+	l.logger.Info("starting listener", "chain_name", l.chainCfg.Name, "chain_id", chainID, "chain", l.chain)
+
+	checkpoint, found, err := l.store.LastHandledBlock(ctx, l.chainID, l.gatewayAddr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load listener checkpoint: %w", err)
+	}
+	if found {
+		l.logger.Info("resuming listener from checkpoint", "block_num", checkpoint, "chain", l.chain)
 	}
+	l.logger.Info("listener finality policy", "policy", l.chainCfg.Finality, "chain", l.chain)
 
 	l.DoneChan = make(chan struct{})
-	l.EventChan = make(chan shared.TransferInitiatedEvent, 10) // Buffer up to 10 events
+	l.NotifyChan = make(chan struct{}, 1)
 
 	go func() {
 		defer close(l.DoneChan)
-		defer close(l.EventChan)
+		defer close(l.NotifyChan)
+		defer l.health.stop()
 
-		ticker := time.NewTicker(5 * time.Second)
+		ticker := time.NewTicker(l.chainCfg.PollInterval)
 		defer ticker.Stop()
 
 		// Blocks up to this value have been handled
-		blockNumHandled := uint64(0)
+		blockNumHandled := checkpoint
 
 		if l.sync {
-			blockNumHandled, err = l.obtainFinalizedBlockNum(ctx)
+			syncStart := uint64(0)
+			if found {
+				syncStart = checkpoint + 1
+			}
+			finalizedBlockNum, err := l.obtainFinalizedBlockNum(ctx)
 			if err != nil {
 				l.logger.Error("failed to obtain block number during sync", "error", err)
+				l.health.failed(err)
 				return
 			}
-			// Most nodes limit query ranges so we fetch in 40k increments
-			events, err := l.obtainTransferInitiatedEventsInBatches(
-				ctx, 0, blockNumHandled)
-			if err != nil {
-				l.logger.Error("failed to fetch transfer initiated events during sync", "error", err)
-				return
+			if found && syncStart <= finalizedBlockNum {
+				// The chain may have reorganized while the relayer was down
+				reorged, err := l.checkReorg(ctx, checkpoint)
+				if err != nil {
+					l.logger.Error("failed to check for chain reorganization during sync", "error", err)
+					l.health.failed(err)
+					return
+				}
+				if reorged != nil {
+					blockNumHandled = reorged.CommonAncestor
+					syncStart = blockNumHandled + 1
+				}
 			}
-			for _, event := range events {
-				l.logger.Info("transfer initiated event seen by listener during sync", "event", event)
-				l.EventChan <- event
+			if syncStart <= finalizedBlockNum {
+				blockNumHandled, err = l.handleBlocks(ctx, syncStart, finalizedBlockNum)
+				if err != nil {
+					l.logger.Error("failed to handle blocks during sync", "error", err)
+					l.health.failed(err)
+					return
+				}
 			}
+			l.recordBlocks(ctx, blockNumHandled)
 		}
+		l.health.start()
+
+		// Events pushed by the subscription only hint at blocks worth handling,
+		// they are still queried from final blocks to be robust against reorgs.
+		hints := make(chan uint64, 1)
+		watcherDone := make(chan struct{})
+		go func() {
+			defer close(watcherDone)
+			l.watchTransferInitiated(ctx, hints)
+		}()
+		defer func() { <-watcherDone }()
 
+		var (
+			wantBlock uint64 // Highest block hinted to contain events
+			lastPoll  time.Time
+		)
 		for {
 			select {
 			case <-ctx.Done():
 				l.logger.Info("listener shutting down", "chain", l.chain)
 				return
+			case blockNum := <-hints:
+				wantBlock = max(wantBlock, blockNum)
 			case <-ticker.C:
+				if l.subscribed.Load() && wantBlock <= blockNumHandled && time.Since(lastPoll) < subscribedPollInterval {
+					continue
+				}
 			}
+			lastPoll = time.Now()
 
+			// The rpc client already failed over between endpoints, so on error
+			// the range after the last handled block is retried on the next tick.
 			currentBlockNum, err := l.obtainFinalizedBlockNum(ctx)
 			if err != nil {
-				// TODO: Secondary url if rpc fails. For now just start over...
-				l.logger.Error("failed to obtain block number", "error", err)
-				l.logger.Warn("listener restarting from block 0...")
-				blockNumHandled = 0
+				l.logger.Error("failed to obtain block number, retrying", "error", err, "chain", l.chain)
+				l.health.failed(err)
 				continue
 			}
 			if blockNumHandled < currentBlockNum {
-				events, err := l.obtainTransferInitiatedEventsInBatches(ctx, blockNumHandled+1, currentBlockNum)
+				reorged, err := l.checkReorg(ctx, blockNumHandled)
+				if err != nil {
+					l.logger.Error("failed to check for chain reorganization", "error", err, "chain", l.chain)
+					l.health.failed(err)
+					continue
+				}
+				if reorged != nil {
+					// Re-handle the blocks after the common ancestor on the next tick
+					blockNumHandled = reorged.CommonAncestor
+					continue
+				}
+				handled, err := l.handleBlocks(ctx, blockNumHandled+1, currentBlockNum)
+				if errors.Is(err, errRangeReorged) {
+					l.logger.Warn("block range reorged while being handled, retrying", "error", err, "chain", l.chain)
+					l.health.failed(err)
+					continue
+				}
 				if err != nil {
-					// TODO: Secondary url if rpc fails. For now just start over...
 					l.logger.Error(
-						"failed to query transfer initiated events",
+						"failed to handle blocks, retrying",
 						"from_block", blockNumHandled+1,
 						"to_block", currentBlockNum,
 						"chain", l.chain,
+						"error", err,
 					)
-					l.logger.Warn("listener restarting from block 0...")
-					blockNumHandled = 0
+					l.health.failed(err)
 					continue
 				}
-				l.logger.Debug(
-					"fetched events",
-					"event_count", len(events),
-					"from_block", blockNumHandled+1,
-					"to_block", currentBlockNum,
-					"chain", l.chain,
-				)
-				for _, event := range events {
-					l.logger.Info("transfer initiated event seen by listener", "event", event)
-					l.EventChan <- event
-				}
-				blockNumHandled = currentBlockNum
+				blockNumHandled = handled
 			}
+			l.health.succeeded()
+			l.recordBlocks(ctx, blockNumHandled)
 		}
 	}()
-	return l.DoneChan, l.EventChan, nil
+	return l.DoneChan, l.NotifyChan, nil
 }
 
-func (l *Listener) obtainFinalizedBlockNum(ctx context.Context) (uint64, error) {
-	blockNum, err := l.rawClient.BlockNumber(ctx)
+// Health returns the health of the listener. Its polls succeed once it handled all final blocks.
+func (l *Listener) Health() ComponentHealth {
+	return l.health.health(false)
+}
+
+// watchTransferInitiated keeps a subscription to transfer initiated events alive until ctx
+// is done and sends the block number of every pushed event to hints. After each (re)subscription
+// the current head is sent as well, so that any gap while unsubscribed is backfilled. When
+// subscriptions are unavailable, e.g. over http, it retries with backoff while the listener polls.
+func (l *Listener) watchTransferInitiated(ctx context.Context, hints chan<- uint64) {
+	delay := minResubscribeDelay
+	unsupportedLogged := false
+	for {
+		err := l.watchUntilError(ctx, hints)
+		if ctx.Err() != nil {
+			return
+		}
+		if l.subscribed.Swap(false) {
+			// The subscription was established before failing, start backing off anew.
+			delay = minResubscribeDelay
+		}
+		switch {
+		case errors.Is(err, rpc.ErrNotificationsUnsupported):
+			delay = maxResubscribeDelay
+			if !unsupportedLogged {
+				l.logger.Info("event subscriptions unsupported by rpc endpoint, polling", "chain", l.chain)
+				unsupportedLogged = true
+			}
+		case err != nil:
+			l.logger.Warn(
+				"transfer initiated subscription failed, polling until resubscribed",
+				"error", err,
+				"retry_in", delay,
+				"chain", l.chain,
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxResubscribeDelay)
+	}
+}
+
+// watchUntilError subscribes to transfer initiated events and forwards their block numbers
+// to hints until the subscription fails or ctx is done. Hints not yet consumed are coalesced.
+func (l *Listener) watchUntilError(ctx context.Context, hints chan<- uint64) error {
+	events := make(chan shared.TransferInitiatedEvent)
+	sub, err := l.gatewayFilterer.WatchTransferInitiatedEvents(&bind.WatchOpts{Context: ctx}, events)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	// Events emitted before the subscription was established are at most at the current head.
+	head, err := l.rawClient.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain head block number: %w", err)
+	}
+	l.subscribed.Store(true)
+	l.logger.Info("subscribed to transfer initiated events", "head", head, "chain", l.chain)
+
+	for hint := head; ; {
+		var out chan<- uint64 // Nil, and thus never ready, while there is nothing to hint
+		if hint > 0 {
+			out = hints
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed by rpc endpoint")
+			}
+			return err
+		case out <- hint:
+			hint = 0
+		case event := <-events:
+			l.logger.Debug("transfer initiated event pushed by subscription", "event", event)
+			hint = max(hint, event.BlockNumber)
+		}
+	}
+}
+
+// errRangeReorged is returned by handleBlocks when the last block of the range
+// changed while the range was being queried.
+var errRangeReorged = errors.New("block range reorged while being queried")
+
+// handleBlocks fetches the transfer initiated events in [fromBlock, toBlock], persists them
+// together with the hash of toBlock and wakes up the transactor consuming the queue.
+// Returns toBlock as the new last handled block.
+func (l *Listener) handleBlocks(ctx context.Context, fromBlock, toBlock uint64) (uint64, error) {
+	toHeader, err := l.rawClient.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return 0, fmt.Errorf("failed to obtain header of block %d: %w", toBlock, err)
+	}
+
+	// Most nodes limit query ranges so we fetch in batches
+	events, err := l.obtainTransferInitiatedEventsInBatches(ctx, fromBlock, toBlock)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query transfer initiated events: %w", err)
+	}
+
+	// Make sure the events were queried from the same chain the hash is remembered for.
+	recheck, err := l.rawClient.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return 0, fmt.Errorf("failed to obtain header of block %d: %w", toBlock, err)
+	}
+	if recheck.Hash() != toHeader.Hash() {
+		return 0, fmt.Errorf("block %d: %w", toBlock, errRangeReorged)
+	}
+
+	l.logger.Debug(
+		"fetched events",
+		"event_count", len(events),
+		"from_block", fromBlock,
+		"to_block", toBlock,
+		"chain", l.chain,
+	)
+	for _, event := range events {
+		l.logger.Info("transfer initiated event seen by listener", "event", event)
+	}
+	err = l.store.SaveHandledEvents(ctx, l.chainID, l.gatewayAddr, events, toBlock, toHeader.Hash())
+	if err != nil {
+		return 0, fmt.Errorf("failed to persist transfer initiated events: %w", err)
+	}
+	l.metrics.EventsSeen(len(events))
+	if len(events) > 0 {
+		select {
+		case l.NotifyChan <- struct{}{}:
+		default: // Transactor already has a pending notification
+		}
+	}
+	return toBlock, nil
+}
+
+// checkReorg verifies that the block after blockNumHandled still builds on the remembered
+// hash of blockNumHandled. If it does not, the chain reorganized: the listener is rolled
+// back to the most recent handled block that is still canonical and the reorg is returned.
+func (l *Listener) checkReorg(ctx context.Context, blockNumHandled uint64) (*store.Reorg, error) {
+	handled, err := l.store.HandledBlocks(ctx, l.chainID, l.gatewayAddr, blockNumHandled)
+	if err != nil {
+		return nil, err
+	}
+	if len(handled) == 0 || handled[0].Number != blockNumHandled {
+		// Nothing to compare against, e.g. the first range after block 0.
+		return nil, nil
+	}
+	next, err := l.rawClient.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumHandled+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain header of block %d: %w", blockNumHandled+1, err)
+	}
+	if next.ParentHash == handled[0].Hash {
+		return nil, nil
+	}
+
+	reorg := &store.Reorg{
+		ChainID:      l.chainID,
+		GatewayAddr:  l.gatewayAddr,
+		DetectedAt:   time.Now(),
+		HandledBlock: blockNumHandled,
+		OldHash:      handled[0].Hash,
+		NewHash:      next.ParentHash,
+	}
+	// Walk back until a remembered hash is still canonical. If none is, resync from block 0.
+	for _, b := range handled[1:] {
+		header, err := l.rawClient.HeaderByNumber(ctx, new(big.Int).SetUint64(b.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain header of block %d: %w", b.Number, err)
+		}
+		if header.Hash() == b.Hash {
+			reorg.CommonAncestor = b.Number
+			break
+		}
+	}
+
+	retracted, inFlight, err := l.store.RollbackListener(ctx, *reorg)
 	if err != nil {
-		return 0, fmt.Errorf("failed to obtain block number: %w", err)
+		return nil, err
 	}
-	// Blocks 2 epochs old are considered finalized
-	epochBlocks := uint64(32)
-	if blockNum < 2*epochBlocks {
-		return 0, nil
+	l.logger.Warn(
+		"chain reorganization detected",
+		"chain", l.chain,
+		"handled_block", reorg.HandledBlock,
+		"common_ancestor", reorg.CommonAncestor,
+		"old_hash", reorg.OldHash.Hex(),
+		"new_hash", reorg.NewHash.Hex(),
+		"retracted_transfers", len(retracted),
+		"in_flight_transfers", len(inFlight),
+	)
+	for _, t := range retracted {
+		l.logger.Warn("transfer retracted due to reorg, it will be re-emitted if still canonical", "transfer", t)
+	}
+	for _, t := range inFlight {
+		l.logger.Error("transfer affected by reorg is already being finalized", "transfer", t)
+	}
+	return reorg, nil
+}
+
+// recordBlocks records the head block of the chain against blockNumHandled, if metrics are recorded.
+func (l *Listener) recordBlocks(ctx context.Context, blockNumHandled uint64) {
+	if l.metrics == nil {
+		return
+	}
+	head, err := l.rawClient.BlockNumber(ctx)
+	if err != nil {
+		l.logger.Warn("failed to obtain head block number for metrics", "error", err, "chain", l.chain)
+		return
+	}
+	l.metrics.Blocks(head, blockNumHandled)
+}
+
+func (l *Listener) obtainFinalizedBlockNum(ctx context.Context) (uint64, error) {
+	blockNum, err := l.chainCfg.Finality.FinalizedBlockNum(ctx, l.rawClient)
+	if err != nil {
+		return 0, fmt.Errorf("failed to obtain finalized block number: %w", err)
 	}
-	return blockNum - 2*epochBlocks, nil
+	return blockNum, nil
 }
 
 func (l *Listener) obtainTransferInitiatedEventsInBatches(
@@ -156,7 +470,7 @@ func (l *Listener) obtainTransferInitiatedEventsInBatches(
 	endBlock uint64,
 ) ([]shared.TransferInitiatedEvent, error) {
 	var totalEvents []shared.TransferInitiatedEvent
-	const maxBlockRange = 40000
+	maxBlockRange := l.chainCfg.BatchSize
 	for start := startBlock; start <= endBlock; start += maxBlockRange + 1 {
 		end := start + maxBlockRange
 		if end > endBlock {
@@ -0,0 +1,191 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"standard-bridge/pkg/shared"
+	"standard-bridge/pkg/store"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// headerBackend serves headers of a fixed canonical chain.
+type headerBackend struct {
+	shared.Backend
+	headers []*types.Header
+}
+
+func (b *headerBackend) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	if !number.IsUint64() || number.Uint64() >= uint64(len(b.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return b.headers[number.Uint64()], nil
+}
+
+// handledBlocksStore serves fixed handled blocks and records rollbacks.
+type handledBlocksStore struct {
+	ListenerStore
+	handled    []store.HandledBlock
+	rolledBack []store.Reorg
+}
+
+func (s *handledBlocksStore) HandledBlocks(
+	_ context.Context,
+	_ *big.Int,
+	_ common.Address,
+	blockNum uint64,
+) ([]store.HandledBlock, error) {
+	var blocks []store.HandledBlock
+	for _, b := range s.handled {
+		if b.Number <= blockNum {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+func (s *handledBlocksStore) RollbackListener(_ context.Context, reorg store.Reorg) ([]store.Transfer, []store.Transfer, error) {
+	s.rolledBack = append(s.rolledBack, reorg)
+	return nil, nil, nil
+}
+
+func TestListenerCheckReorg(t *testing.T) {
+	headers := make([]*types.Header, 12)
+	for i := range headers {
+		headers[i] = &types.Header{Number: big.NewInt(int64(i))}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+	}
+	canonical := func(numbers ...uint64) []store.HandledBlock {
+		var blocks []store.HandledBlock
+		for _, n := range numbers {
+			blocks = append(blocks, store.HandledBlock{Number: n, Hash: headers[n].Hash()})
+		}
+		return blocks
+	}
+	stale := func(numbers ...uint64) []store.HandledBlock {
+		var blocks []store.HandledBlock
+		for _, n := range numbers {
+			blocks = append(blocks, store.HandledBlock{Number: n, Hash: common.BigToHash(big.NewInt(int64(1000 + n)))})
+		}
+		return blocks
+	}
+
+	tests := []struct {
+		name         string
+		handled      []store.HandledBlock // Most recent first.
+		blockNum     uint64
+		wantReorg    bool
+		wantAncestor uint64
+	}{
+		{
+			name:     "nothing handled",
+			blockNum: 10,
+		},
+		{
+			name:     "handled block not remembered",
+			handled:  canonical(8, 6, 4),
+			blockNum: 10,
+		},
+		{
+			name:     "still canonical",
+			handled:  canonical(10, 8, 6),
+			blockNum: 10,
+		},
+		{
+			name:         "ancestor is previous handled block",
+			handled:      append(stale(10), canonical(8, 6)...),
+			blockNum:     10,
+			wantReorg:    true,
+			wantAncestor: 8,
+		},
+		{
+			name:         "ancestor is older handled block",
+			handled:      append(stale(10, 8, 6), canonical(4, 2)...),
+			blockNum:     10,
+			wantReorg:    true,
+			wantAncestor: 4,
+		},
+		{
+			name:         "no handled block is canonical",
+			handled:      stale(10, 8, 6),
+			blockNum:     10,
+			wantReorg:    true,
+			wantAncestor: 0,
+		},
+		{
+			name:         "handled blocks above blockNum are ignored",
+			handled:      append(canonical(11), append(stale(9), canonical(7)...)...),
+			blockNum:     9,
+			wantReorg:    true,
+			wantAncestor: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &handledBlocksStore{handled: tt.handled}
+			l := &Listener{
+				logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+				rawClient: &headerBackend{headers: headers},
+				store:     s,
+				chainID:   big.NewInt(1),
+			}
+			reorg, err := l.checkReorg(context.Background(), tt.blockNum)
+			if err != nil {
+				t.Fatalf("checkReorg() error = %v", err)
+			}
+			if !tt.wantReorg {
+				if reorg != nil || len(s.rolledBack) != 0 {
+					t.Fatalf("checkReorg() = %+v, rollbacks %d, want no reorg", reorg, len(s.rolledBack))
+				}
+				return
+			}
+			if reorg == nil {
+				t.Fatal("checkReorg() = nil, want reorg")
+			}
+			if reorg.CommonAncestor != tt.wantAncestor {
+				t.Errorf("CommonAncestor = %d, want %d", reorg.CommonAncestor, tt.wantAncestor)
+			}
+			if reorg.HandledBlock != tt.blockNum {
+				t.Errorf("HandledBlock = %d, want %d", reorg.HandledBlock, tt.blockNum)
+			}
+			for _, b := range tt.handled {
+				if b.Number == tt.blockNum && reorg.OldHash != b.Hash {
+					t.Errorf("OldHash = %s, want %s", reorg.OldHash, b.Hash)
+				}
+			}
+			if want := headers[tt.blockNum].Hash(); reorg.NewHash != want {
+				t.Errorf("NewHash = %s, want %s", reorg.NewHash, want)
+			}
+			if len(s.rolledBack) != 1 || s.rolledBack[0] != *reorg {
+				t.Errorf("rollbacks = %+v, want exactly the returned reorg", s.rolledBack)
+			}
+		})
+	}
+}
+
+func TestListenerCheckReorgHeaderError(t *testing.T) {
+	headers := []*types.Header{{Number: big.NewInt(0)}}
+	s := &handledBlocksStore{handled: []store.HandledBlock{{Number: 5, Hash: common.HexToHash("0x05")}}}
+	l := &Listener{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		rawClient: &headerBackend{headers: headers},
+		store:     s,
+		chainID:   big.NewInt(1),
+	}
+	if _, err := l.checkReorg(context.Background(), 5); !errors.Is(err, ethereum.NotFound) {
+		t.Fatalf("checkReorg() error = %v, want %v", err, ethereum.NotFound)
+	}
+	if len(s.rolledBack) != 0 {
+		t.Fatalf("rollbacks = %d, want 0", len(s.rolledBack))
+	}
+}
@@ -2,31 +2,49 @@ package relayer
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"standard-bridge/pkg/metrics"
 	"standard-bridge/pkg/shared"
+	"standard-bridge/pkg/store"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	l1g "github.com/primevprotocol/contracts-abi/clients/L1Gateway"
 	sg "github.com/primevprotocol/contracts-abi/clients/SettlementGateway"
-	"golang.org/x/crypto/sha3"
 )
 
 type Options struct {
-	Ctx                    context.Context
-	Logger                 *slog.Logger
-	PrivateKey             *ecdsa.PrivateKey
-	SettlementRPCUrl       string
-	L1RPCUrl               string
+	Ctx    context.Context
+	Logger *slog.Logger
+	// Signer signs the finalization txs on both chains.
+	Signer shared.Signer
+	// RPC endpoints of each chain, calls fail over between them.
+	SettlementRPCUrls      []string
+	L1RPCUrls              []string
 	L1ContractAddr         common.Address
 	SettlementContractAddr common.Address
+	DBPath                 string
+	Chains                 *shared.ChainRegistry
+	// Overrides of the finality policies of the chain registry, if not nil.
+	L1Finality         *shared.FinalityPolicy
+	SettlementFinality *shared.FinalityPolicy
+	// Overrides of the gas strategies of the chain registry, if not empty.
+	L1GasStrategy         string
+	SettlementGasStrategy string
+	// PendingTxMode is how txs left pending by a previous run are dealt with on start.
+	PendingTxMode PendingTxMode
+	// Limits on the finalizations of each direction, transfers exceeding them are held.
+	L1ToSettlementLimits Limits
+	SettlementToL1Limits Limits
+	// Files listing the addresses transfers are screened against, screening is disabled if both are empty.
+	DenylistFiles  []string
+	AllowlistFiles []string
+	// Metrics records the metrics of listeners and transactors, nothing is recorded if nil.
+	Metrics *metrics.Metrics
 }
 
 type Relayer struct {
@@ -34,23 +52,32 @@ type Relayer struct {
 	// Closes ctx's Done channel and waits for all goroutines to close.
 	waitOnCloseRoutines func()
 	db                  *sql.DB
+	rpcClients          []*shared.MultiClient
+	// Components reported by Health.
+	l1Client         *shared.MultiClient
+	settlementClient *shared.MultiClient
+	listeners        []*Listener
+	transactors      []*Transactor
+	// store and screener are read by the admin API.
+	store    *store.Store
+	screener *Screener
 }
 
 func NewRelayer(opts *Options) (r *Relayer, err error) {
 	r = &Relayer{logger: opts.Logger}
 
-	pubKey := &opts.PrivateKey.PublicKey
-	pubKeyBytes := crypto.FromECDSAPub(pubKey)
-	hash := sha3.NewLegacyKeccak256()
-	hash.Write(pubKeyBytes[1:])
-	address := hash.Sum(nil)[12:]
+	r.logger.Info("relayer signing address", "address", opts.Signer.Address().Hex())
 
-	r.logger.Info("relayer signing address", "address", common.BytesToAddress(address).Hex())
-
-	l1Client, err := ethclient.DialContext(opts.Ctx, opts.L1RPCUrl)
+	l1Client, err := shared.DialMultiClient(opts.Ctx, r.logger.With("component", "l1_rpc"), opts.L1RPCUrls)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial l1 rpc: %w", err)
 	}
+	r.rpcClients = append(r.rpcClients, l1Client)
+	defer func() {
+		if err != nil {
+			r.closeRPCClients()
+		}
+	}()
 
 	l1ChainID, err := l1Client.ChainID(opts.Ctx)
 	if err != nil {
@@ -58,17 +85,66 @@ func NewRelayer(opts *Options) (r *Relayer, err error) {
 	}
 	r.logger.Info("L1 chain id", "chain_id", l1ChainID)
 
-	settlementClient, err := ethclient.Dial(opts.SettlementRPCUrl)
+	l1Chain, err := opts.Chains.LookupRole(l1ChainID, shared.L1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid l1 chain: %w", err)
+	}
+	if opts.L1Finality != nil {
+		l1Chain.Finality = *opts.L1Finality
+	}
+	if opts.L1GasStrategy != "" {
+		l1Chain.Gas.Strategy = opts.L1GasStrategy
+		if err := l1Chain.Gas.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid l1 gas strategy override: %w", err)
+		}
+	}
+
+	settlementClient, err := shared.DialMultiClient(
+		opts.Ctx,
+		r.logger.With("component", "settlement_rpc"),
+		opts.SettlementRPCUrls,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial settlement rpc: %w", err)
 	}
+	r.rpcClients = append(r.rpcClients, settlementClient)
 
 	settlementChainID, err := settlementClient.ChainID(opts.Ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to dial settlement rpc: %w", err)
+		return nil, fmt.Errorf("failed to get settlement chain id: %w", err)
 	}
 	r.logger.Info("settlement chain id", "chain_id", settlementChainID)
 
+	settlementChain, err := opts.Chains.LookupRole(settlementChainID, shared.Settlement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid settlement chain: %w", err)
+	}
+	if opts.SettlementFinality != nil {
+		settlementChain.Finality = *opts.SettlementFinality
+	}
+	if opts.SettlementGasStrategy != "" {
+		settlementChain.Gas.Strategy = opts.SettlementGasStrategy
+		if err := settlementChain.Gas.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid settlement gas strategy override: %w", err)
+		}
+	}
+
+	r.db, err = store.OpenDB(opts.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = r.db.Close()
+		}
+	}()
+	st, err := store.New(opts.Ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize store: %w", err)
+	}
+	r.store = st
+	r.logger.Info("opened relayer db", "path", opts.DBPath)
+
 	sFilterer, err := shared.NewSettlementFilterer(opts.SettlementContractAddr, settlementClient)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create settlement filterer: %w", err)
@@ -81,8 +157,31 @@ func NewRelayer(opts *Options) (r *Relayer, err error) {
 		}
 	}()
 
-	sListener := NewListener(r.logger.With("component", "settlement_listener"), settlementClient, sFilterer, false)
-	sListenerClosed, settlementEventChan, err := sListener.Start(ctx)
+	l1Metrics := opts.Metrics.Chain("l1")
+	settlementMetrics := opts.Metrics.Chain("settlement")
+
+	l1ProbeClosed := l1Client.Start(ctx)
+	settlementProbeClosed := settlementClient.Start(ctx)
+
+	if len(opts.DenylistFiles) > 0 || len(opts.AllowlistFiles) > 0 {
+		r.screener, err = NewScreener(r.logger.With("component", "screener"), opts.DenylistFiles, opts.AllowlistFiles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load screening lists: %w", err)
+		}
+	}
+	screenerClosed := r.screener.Start(ctx)
+
+	sListener := NewListener(
+		r.logger.With("component", "settlement_listener"),
+		settlementClient,
+		opts.SettlementContractAddr,
+		sFilterer,
+		st,
+		settlementChain,
+		false,
+		settlementMetrics,
+	)
+	sListenerClosed, settlementNotifyChan, err := sListener.Start(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start settlement listener: %w", err)
 	}
@@ -92,24 +191,45 @@ func NewRelayer(opts *Options) (r *Relayer, err error) {
 		return nil, fmt.Errorf("failed to create l1 filterer: %w", err)
 	}
 
-	l1Listener := NewListener(r.logger.With("component", "l1_listener"), l1Client, l1Filterer, true)
-	l1ListenerClosed, l1EventChan, err := l1Listener.Start(ctx)
+	l1Listener := NewListener(
+		r.logger.With("component", "l1_listener"),
+		l1Client,
+		opts.L1ContractAddr,
+		l1Filterer,
+		st,
+		l1Chain,
+		true,
+		l1Metrics,
+	)
+	l1ListenerClosed, l1NotifyChan, err := l1Listener.Start(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start l1 listener: %w", err)
 	}
 
-	st, err := sg.NewSettlementgatewayTransactor(opts.SettlementContractAddr, settlementClient)
+	sgt, err := sg.NewSettlementgatewayTransactor(opts.SettlementContractAddr, settlementClient)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create settlement gateway transactor: %w", err)
 	}
+	sgc, err := sg.NewSettlementgatewayCaller(opts.SettlementContractAddr, settlementClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create settlement gateway caller: %w", err)
+	}
 	settlementTransactor := NewTransactor(
 		r.logger.With("component", "settlement_transactor"),
-		opts.PrivateKey,
+		opts.Signer,
 		opts.SettlementContractAddr,
 		settlementClient,
-		st,
+		sgt,
+		sgc,
 		sFilterer,
-		l1EventChan, // L1 transfer initiations result in settlement finalizations
+		settlementChain,
+		st,
+		l1ChainID,
+		l1NotifyChan, // L1 transfer initiations result in settlement finalizations
+		opts.PendingTxMode,
+		opts.L1ToSettlementLimits,
+		r.screener,
+		settlementMetrics,
 	)
 	stClosed, err := settlementTransactor.Start(ctx)
 	if err != nil {
@@ -120,20 +240,36 @@ func NewRelayer(opts *Options) (r *Relayer, err error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create l1 gateway transactor: %w", err)
 	}
+	l1c, err := l1g.NewL1gatewayCaller(opts.L1ContractAddr, l1Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create l1 gateway caller: %w", err)
+	}
 	l1Transactor := NewTransactor(
 		r.logger.With("component", "l1_transactor"),
-		opts.PrivateKey,
+		opts.Signer,
 		opts.L1ContractAddr,
 		l1Client,
 		l1t,
+		l1c,
 		l1Filterer,
-		settlementEventChan, // Settlement transfer initiations result in L1 finalizations
+		l1Chain,
+		st,
+		settlementChainID,
+		settlementNotifyChan, // Settlement transfer initiations result in L1 finalizations
+		opts.PendingTxMode,
+		opts.SettlementToL1Limits,
+		r.screener,
+		l1Metrics,
 	)
 	l1tClosed, err := l1Transactor.Start(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	r.l1Client, r.settlementClient = l1Client, settlementClient
+	r.listeners = []*Listener{l1Listener, sListener}
+	r.transactors = []*Transactor{l1Transactor, settlementTransactor}
+
 	r.waitOnCloseRoutines = func() {
 		// Close ctx's Done channel
 		cancel()
@@ -145,15 +281,19 @@ func NewRelayer(opts *Options) (r *Relayer, err error) {
 			<-l1ListenerClosed
 			<-stClosed
 			<-l1tClosed
+			<-l1ProbeClosed
+			<-settlementProbeClosed
+			<-screenerClosed
 		}()
 		<-allClosed
 	}
 	return r, nil
 }
 
-// TryCloseAll attempts to close all workers and the database connection.
+// TryCloseAll attempts to close all workers, rpc connections and the database connection.
 func (r *Relayer) TryCloseAll() (err error) {
 	r.logger.Debug("closing all workers and db connection")
+	defer r.closeRPCClients()
 	defer func() {
 		if r.db == nil {
 			return
@@ -179,3 +319,9 @@ func (r *Relayer) TryCloseAll() (err error) {
 		return errors.New(msg)
 	}
 }
+
+func (r *Relayer) closeRPCClients() {
+	for _, c := range r.rpcClients {
+		c.Close()
+	}
+}
@@ -0,0 +1,251 @@
+package relayer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// screeningReloadInterval is how often the screening list files are checked for changes.
+const screeningReloadInterval = 10 * time.Second
+
+const (
+	listDeny  = "denylist"
+	listAllow = "allowlist"
+)
+
+// ScreeningMatch is why a transfer fails screening: the address of one of its parties is on
+// a denylist, or missing from all allowlists.
+type ScreeningMatch struct {
+	Party   string
+	Address common.Address
+	List    string
+	// File is the denylist the address is on, empty for allowlist matches.
+	File string
+}
+
+func (m *ScreeningMatch) Error() string {
+	if m.List == listAllow {
+		return fmt.Sprintf("%s %s is not on any allowlist", m.Party, m.Address.Hex())
+	}
+	return fmt.Sprintf("%s %s is on denylist %s", m.Party, m.Address.Hex(), m.File)
+}
+
+// ScreeningState is the state of the screening lists shown to operators.
+type ScreeningState struct {
+	Denylists   []string   `json:"denylists"`
+	Allowlists  []string   `json:"allowlists"`
+	Denied      int        `json:"denied_addresses"`
+	Allowed     int        `json:"allowed_addresses"`
+	LoadedAt    time.Time  `json:"loaded_at"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// fileStamp identifies a version of a list file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Screener checks the parties of transfers against denylist and allowlist files, which are
+// reloaded whenever they change. If any allowlist is configured, both parties must be on one.
+// A nil Screener passes every transfer.
+type Screener struct {
+	logger     *slog.Logger
+	denyFiles  []string
+	allowFiles []string
+
+	mu        sync.RWMutex
+	denied    map[common.Address]string // Address -> denylist file
+	allowed   map[common.Address]struct{}
+	stamps    map[string]fileStamp
+	loadedAt  time.Time
+	lastErr   error
+	lastErrAt time.Time
+}
+
+// NewScreener loads the list files, failing if any cannot be loaded.
+func NewScreener(logger *slog.Logger, denyFiles, allowFiles []string) (*Screener, error) {
+	s := &Screener{logger: logger, denyFiles: denyFiles, allowFiles: allowFiles}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start reloads the list files on changes until ctx is done. A list file that fails to
+// load keeps the previous lists in use. The returned channel is closed once reloading stopped,
+// right away for a nil Screener.
+func (s *Screener) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if s == nil {
+		close(done)
+		return done
+	}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(screeningReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !s.changed() {
+				continue
+			}
+			if err := s.load(); err != nil {
+				s.logger.Error("failed to reload screening lists, keeping previous lists", "error", err)
+				s.mu.Lock()
+				s.lastErr, s.lastErrAt = err, time.Now()
+				s.mu.Unlock()
+			}
+		}
+	}()
+	return done
+}
+
+// Screen returns the first match of sender or recipient, nil if the transfer passes.
+func (s *Screener) Screen(sender, recipient common.Address) *ScreeningMatch {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	parties := []struct {
+		name    string
+		address common.Address
+	}{{"sender", sender}, {"recipient", recipient}}
+	for _, p := range parties {
+		if file, ok := s.denied[p.address]; ok {
+			return &ScreeningMatch{Party: p.name, Address: p.address, List: listDeny, File: file}
+		}
+	}
+	if len(s.allowFiles) == 0 {
+		return nil
+	}
+	for _, p := range parties {
+		if _, ok := s.allowed[p.address]; !ok {
+			return &ScreeningMatch{Party: p.name, Address: p.address, List: listAllow}
+		}
+	}
+	return nil
+}
+
+// State returns the state of the screening lists.
+func (s *Screener) State() ScreeningState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state := ScreeningState{
+		Denylists:  append([]string{}, s.denyFiles...),
+		Allowlists: append([]string{}, s.allowFiles...),
+		Denied:     len(s.denied),
+		Allowed:    len(s.allowed),
+		LoadedAt:   s.loadedAt,
+	}
+	if s.lastErr != nil {
+		lastErrAt := s.lastErrAt
+		state.LastError, state.LastErrorAt = s.lastErr.Error(), &lastErrAt
+	}
+	return state
+}
+
+// changed reports whether any list file changed since it was last loaded.
+func (s *Screener) changed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, path := range append(append([]string{}, s.denyFiles...), s.allowFiles...) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return true // Reported by load.
+		}
+		if s.stamps[path] != (fileStamp{modTime: info.ModTime(), size: info.Size()}) {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads all list files and swaps them in if all of them could be read.
+func (s *Screener) load() error {
+	var (
+		denied  = make(map[common.Address]string)
+		allowed = make(map[common.Address]struct{})
+		stamps  = make(map[string]fileStamp)
+	)
+	for _, path := range s.denyFiles {
+		addrs, stamp, err := readAddressList(path)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			if _, ok := denied[addr]; !ok {
+				denied[addr] = path
+			}
+		}
+		stamps[path] = stamp
+	}
+	for _, path := range s.allowFiles {
+		addrs, stamp, err := readAddressList(path)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			allowed[addr] = struct{}{}
+		}
+		stamps[path] = stamp
+	}
+
+	s.mu.Lock()
+	s.denied, s.allowed, s.stamps = denied, allowed, stamps
+	s.loadedAt, s.lastErr = time.Now(), nil
+	s.mu.Unlock()
+	s.logger.Info(
+		"loaded screening lists",
+		"denylists", len(s.denyFiles),
+		"denied_addresses", len(denied),
+		"allowlists", len(s.allowFiles),
+		"allowed_addresses", len(allowed),
+	)
+	return nil
+}
+
+// readAddressList reads a list file holding one address per line. Blank lines and
+// everything after a # are ignored.
+func readAddressList(path string) ([]common.Address, fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fileStamp{}, fmt.Errorf("failed to stat screening list %s: %w", path, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fileStamp{}, fmt.Errorf("failed to read screening list %s: %w", path, err)
+	}
+	var addrs []common.Address
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !common.IsHexAddress(line) {
+			return nil, fileStamp{}, fmt.Errorf("screening list %s: line %d: invalid address %q", path, n, line)
+		}
+		addrs = append(addrs, common.HexToAddress(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fileStamp{}, fmt.Errorf("failed to scan screening list %s: %w", path, err)
+	}
+	return addrs, fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
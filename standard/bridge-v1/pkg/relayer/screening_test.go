@@ -0,0 +1,130 @@
+package relayer
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func writeList(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+	return path
+}
+
+func TestScreenerScreen(t *testing.T) {
+	var (
+		alice   = common.HexToAddress("0x000000000000000000000000000000000000a11c")
+		bob     = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+		mallory = common.HexToAddress("0x000000000000000000000000000000000000ba11")
+	)
+	denylist := writeList(t, "deny.txt", "# sanctioned\n"+mallory.Hex()+" # added by compliance\n\n")
+	allowlist := writeList(t, "allow.txt", alice.Hex()+"\n"+bob.Hex()+"\n"+mallory.Hex()+"\n")
+
+	tests := []struct {
+		name       string
+		deny       []string
+		allow      []string
+		sender     common.Address
+		recipient  common.Address
+		wantParty  string
+		wantList   string
+		wantPasses bool
+	}{
+		{
+			name:       "no lists",
+			sender:     mallory,
+			recipient:  mallory,
+			wantPasses: true,
+		},
+		{
+			name:       "denylist only, parties not on it",
+			deny:       []string{denylist},
+			sender:     alice,
+			recipient:  bob,
+			wantPasses: true,
+		},
+		{
+			name:      "denied sender",
+			deny:      []string{denylist},
+			sender:    mallory,
+			recipient: bob,
+			wantParty: "sender",
+			wantList:  listDeny,
+		},
+		{
+			name:      "denied recipient",
+			deny:      []string{denylist},
+			sender:    alice,
+			recipient: mallory,
+			wantParty: "recipient",
+			wantList:  listDeny,
+		},
+		{
+			name:      "denylist takes precedence over allowlist",
+			deny:      []string{denylist},
+			allow:     []string{allowlist},
+			sender:    alice,
+			recipient: mallory,
+			wantParty: "recipient",
+			wantList:  listDeny,
+		},
+		{
+			name:       "both parties allowed",
+			allow:      []string{allowlist},
+			sender:     alice,
+			recipient:  bob,
+			wantPasses: true,
+		},
+		{
+			name:      "recipient missing from allowlists",
+			allow:     []string{allowlist},
+			sender:    alice,
+			recipient: common.HexToAddress("0x01"),
+			wantParty: "recipient",
+			wantList:  listAllow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScreener(slog.New(slog.NewTextHandler(io.Discard, nil)), tt.deny, tt.allow)
+			if err != nil {
+				t.Fatalf("failed to create screener: %v", err)
+			}
+			match := s.Screen(tt.sender, tt.recipient)
+			if tt.wantPasses {
+				if match != nil {
+					t.Fatalf("Screen() = %v, want pass", match)
+				}
+				return
+			}
+			if match == nil {
+				t.Fatal("Screen() passed, want match")
+			}
+			if match.Party != tt.wantParty || match.List != tt.wantList {
+				t.Fatalf("Screen() = %s on %s, want %s on %s", match.Party, match.List, tt.wantParty, tt.wantList)
+			}
+		})
+	}
+}
+
+func TestNilScreenerPasses(t *testing.T) {
+	var s *Screener
+	if match := s.Screen(common.Address{}, common.Address{}); match != nil {
+		t.Fatalf("Screen() = %v, want pass", match)
+	}
+}
+
+func TestNewScreenerRejectsInvalidAddresses(t *testing.T) {
+	denylist := writeList(t, "deny.txt", "0x1234\n")
+	if _, err := NewScreener(slog.New(slog.NewTextHandler(io.Discard, nil)), []string{denylist}, nil); err == nil {
+		t.Fatal("expected invalid address to be rejected")
+	}
+}
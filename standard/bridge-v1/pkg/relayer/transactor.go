@@ -2,31 +2,129 @@ package relayer
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
+	"strings"
+	"sync"
+	"time"
 
+	"standard-bridge/pkg/metrics"
 	"standard-bridge/pkg/shared"
+	"standard-bridge/pkg/store"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+const (
+	// maxFinalizationAttempts is the number of attempts after which a transfer is dead-lettered.
+	maxFinalizationAttempts = 5
+	// failedRetryDelay is the minimum delay before a failed transfer is attempted again.
+	failedRetryDelay = time.Minute
+	// queuePollInterval is how often the queue is checked without a listener notification.
+	queuePollInterval = 10 * time.Second
+	// finalityPollInterval is how often a mined finalization tx is checked for finality.
+	finalityPollInterval = 5 * time.Second
+)
+
+// PendingTxMode is how a transactor deals with txs of the relayer account left pending by a previous run.
+type PendingTxMode string
+
+const (
+	// PendingTxAdopt resumes waiting on pending finalization txs of queued transfers, bumping
+	// their fees as usual, and cancels any other pending tx.
+	PendingTxAdopt PendingTxMode = "adopt"
+	// PendingTxCancel replaces pending txs with 0-value self-sends, their transfers are finalized again.
+	PendingTxCancel PendingTxMode = "cancel"
+	// PendingTxSpeedUp rebroadcasts pending txs with bumped fees, so that they complete.
+	PendingTxSpeedUp PendingTxMode = "speed-up"
+)
+
+// errTransferInFlight is returned by operator actions on a transfer that is being finalized.
+var errTransferInFlight = errors.New("transfer is being finalized")
+
+// errFinalizationBlocked is returned by prepare for a transfer that cannot be finalized
+// before an earlier transfer is, while no finalization is in flight.
+var errFinalizationBlocked = errors.New("transfer finalization blocked by an earlier transfer")
+
+// TransferQueue is the durable queue of transfers awaiting finalization.
+type TransferQueue interface {
+	PendingTransfers(ctx context.Context, srcChainID *big.Int, retryDelay time.Duration) ([]store.Transfer, error)
+	MarkTransferSubmitted(ctx context.Context, srcChainID, transferIdx *big.Int, txHash common.Hash) error
+	MarkTransferMined(ctx context.Context, srcChainID, transferIdx *big.Int, txHash common.Hash) error
+	MarkTransferConfirmed(ctx context.Context, srcChainID, transferIdx *big.Int) error
+	MarkTransferQuarantined(ctx context.Context, srcChainID, transferIdx *big.Int, reason error) error
+	MarkTransferFailed(
+		ctx context.Context,
+		srcChainID, transferIdx *big.Int,
+		reason error,
+		maxAttempts int,
+	) (store.TransferStatus, error)
+	MarkTransferSkipped(ctx context.Context, srcChainID, transferIdx *big.Int, reason string) error
+	MarkTransferHeld(ctx context.Context, srcChainID, transferIdx *big.Int, reason string) error
+	HoldScreenedTransfer(ctx context.Context, hold store.ScreeningHold) error
+	ReleaseTransfer(ctx context.Context, srcChainID, transferIdx *big.Int) error
+	RequeueTransfer(ctx context.Context, srcChainID, transferIdx *big.Int) error
+	TransfersSubmittedSince(ctx context.Context, srcChainID *big.Int, since time.Time) ([]store.Transfer, error)
+	PauseQueue(ctx context.Context, srcChainID *big.Int, reason string) error
+	ResumeQueue(ctx context.Context, srcChainID *big.Int) error
+	QueuePaused(ctx context.Context, srcChainID *big.Int) (store.QueuePause, bool, error)
+}
+
 type Transactor struct {
 	logger            *slog.Logger
-	privateKey        *ecdsa.PrivateKey
+	signer            shared.Signer
+	gatewayAddr       common.Address
 	rawClient         *shared.ETHClient
 	gatewayTransactor shared.GatewayTransactor
+	gatewayCaller     shared.GatewayCaller
 	gatewayFilterer   shared.GatewayFilterer
 	chainID           *big.Int
 	chain             shared.Chain
-	eventChan         <-chan shared.TransferInitiatedEvent
+	chainCfg          shared.ChainConfig
+	queue             TransferQueue
+	srcChainID        *big.Int
+	notifyChan        <-chan struct{}
+	pendingTxMode     PendingTxMode
+	metrics           *metrics.ChainMetrics
+	health            *healthTracker
+	// direction names the transfers finalized by the transactor, e.g. l1_to_settlement.
+	direction string
+	// wake is signaled by operator actions for the queue to be read right away.
+	wake chan struct{}
+	// slots limits the number of finalizations in flight.
+	slots   chan struct{}
+	workers sync.WaitGroup
+	// mu guards dispatched, preparing, pause, limiter and mostRecentFinalized, which are
+	// shared with workers and operator actions.
+	mu sync.Mutex
+	// dispatched holds the transfers handed to a worker since the queue was last read,
+	// keyed by source transfer idx. The value is true while the worker is running.
+	// Transfers changed by an operator are added as not running, so that they are
+	// only dispatched again once the queue reflects the change.
+	dispatched map[string]bool
+	// preparing is the transfer whose finalization tx is being prepared, if any.
+	preparing string
+	// pause is set while no new finalizations are dispatched.
+	pause *store.QueuePause
+	// limiter holds transfers exceeding the finalization limits.
+	limiter *limiter
+	// screener holds transfers whose sender or recipient fails screening, nil if disabled.
+	screener *Screener
 	mostRecentFinalized
 }
 
+// finalizationTx is a finalization tx prepared for submission.
+type finalizationTx struct {
+	opts        *bind.TransactOpts
+	gasEstimate uint64
+	// adopted is the pending tx sent by a previous run, if the finalization was adopted.
+	adopted *gethtypes.Transaction
+}
+
 type mostRecentFinalized struct {
 	event shared.TransferFinalizedEvent
 	opts  bind.FilterOpts
@@ -38,23 +136,48 @@ func (m mostRecentFinalized) String() string {
 
 func NewTransactor(
 	logger *slog.Logger,
-	pk *ecdsa.PrivateKey,
+	signer shared.Signer,
 	gatewayAddr common.Address,
-	ethClient *ethclient.Client,
+	ethClient shared.Backend,
 	gatewayTransactor shared.GatewayTransactor,
+	gatewayCaller shared.GatewayCaller,
 	gatewayFilterer shared.GatewayFilterer,
-	eventChan <-chan shared.TransferInitiatedEvent,
+	chainCfg shared.ChainConfig,
+	queue TransferQueue,
+	srcChainID *big.Int,
+	notifyChan <-chan struct{},
+	pendingTxMode PendingTxMode,
+	limits Limits,
+	screener *Screener,
+	chainMetrics *metrics.ChainMetrics,
 ) *Transactor {
+	rawClient := shared.NewETHClient(
+		logger.With("component", "eth_client"),
+		ethClient,
+		chainCfg.Gas,
+	)
+	rawClient.SetMetrics(chainMetrics)
 	return &Transactor{
-		logger:     logger,
-		privateKey: pk,
-		rawClient: shared.NewETHClient(
-			logger.With("component", "eth_client"),
-			ethClient,
-		),
+		logger:            logger,
+		signer:            signer,
+		gatewayAddr:       gatewayAddr,
+		rawClient:         rawClient,
 		gatewayTransactor: gatewayTransactor,
+		gatewayCaller:     gatewayCaller,
 		gatewayFilterer:   gatewayFilterer,
-		eventChan:         eventChan,
+		chainCfg:          chainCfg,
+		queue:             queue,
+		srcChainID:        srcChainID,
+		notifyChan:        notifyChan,
+		pendingTxMode:     pendingTxMode,
+		metrics:           chainMetrics,
+		health:            newHealthTracker(strings.ToLower(chainCfg.Role.String())+"_transactor", stallPolls*queuePollInterval),
+		direction:         directionName(chainCfg.Role),
+		wake:              make(chan struct{}, 1),
+		slots:             make(chan struct{}, chainCfg.MaxInFlight),
+		dispatched:        make(map[string]bool),
+		limiter:           newLimiter(limits),
+		screener:          screener,
 		mostRecentFinalized: mostRecentFinalized{
 			event: shared.TransferFinalizedEvent{},
 			opts:  bind.FilterOpts{Start: 0, End: nil}, // TODO: cache doesn't need to start at 0 once non-syncing relayer is implemented
@@ -68,91 +191,633 @@ func (t *Transactor) Start(ctx context.Context) (<-chan struct{}, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chain id: %w", err)
 	}
-	switch t.chainID.String() {
-	case "39999":
-		t.logger.Info("starting transactor for local_l1")
-		t.chain = shared.L1
-	case "17000":
-		t.logger.Info("starting transactor for Holesky L1")
-		t.chain = shared.L1
-	case "17864":
-		t.logger.Info("starting transactor for mev-commit chain (settlement)")
-		t.chain = shared.Settlement
-	default:
-		return nil, fmt.Errorf("unsupported chain id: %s", t.chainID)
+	if t.chainID.Uint64() != t.chainCfg.ChainID {
+		return nil, fmt.Errorf("chain id mismatch, expected %d, obtained %s", t.chainCfg.ChainID, t.chainID)
+	}
+	t.chain = t.chainCfg.Role
+	t.logger.Info("starting transactor", "chain_name", t.chainCfg.Name, "chain_id", t.chainID, "chain", t.chain)
+
+	pause, paused, err := t.queue.QueuePaused(ctx, t.srcChainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if queue is paused: %w", err)
+	}
+	if paused {
+		t.pause = &pause
+		t.logger.Warn("transactor queue is paused", "direction", t.direction, "reason", pause.Reason, "paused_at", pause.PausedAt)
+	}
+	if t.limiter.limits.windowed() {
+		// Finalizations sent by previous runs count against the window limits.
+		submitted, err := t.queue.TransfersSubmittedSince(ctx, t.srcChainID, time.Now().Add(-t.limiter.limits.Window))
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain submitted transfers: %w", err)
+		}
+		for _, transfer := range submitted {
+			t.limiter.record(transfer.SubmittedAt, transfer.Event.Amount)
+		}
 	}
 
 	doneChan := make(chan struct{})
 
 	go func() {
 		defer close(doneChan)
+		defer t.workers.Wait()
+		defer t.health.stop()
 
-		if err := t.rawClient.CancelPendingTxes(ctx, t.privateKey); err != nil {
-			t.logger.Error("failed to cancel pending transactions", "error", err)
-		}
+		t.resolvePendingTxes(ctx)
+		t.health.start()
 
-		for event := range t.eventChan {
-			t.logger.Debug(
-				"received signal from listener to submit transfer finalization tx",
-				"dst_chain", t.chain,
-				"src_chain", event.Chain,
-				"recipient", event.Recipient,
-				"amount", event.Amount,
-				"src_transfer_idx", event.TransferIdx,
-			)
-			opts, err := t.rawClient.CreateTransactOpts(ctx, t.privateKey, t.chainID)
-			if err != nil {
-				t.logger.Error("failed to create transact opts for transfer finalization tx", "error", err)
-				t.logger.Warn("skipping transfer finalization tx", "src_transfer_idx", event.TransferIdx)
-				continue
-			}
-			finalized, err := t.transferAlreadyFinalized(ctx, event.TransferIdx)
-			if err != nil {
-				t.logger.Error("failed to check if transfer already finalized")
-				t.logger.Warn("skipping transfer finalization tx", "src_transfer_idx", event.TransferIdx)
-				continue
-			}
-			if finalized {
-				continue
-			}
-			receipt, err := t.sendFinalizeTransfer(ctx, opts, event)
-			if err != nil {
-				t.logger.Error("failed to send transfer finalization tx")
-				t.logger.Warn("skipping transfer finalization tx", "src_transfer_idx", event.TransferIdx)
-				continue
-			}
-			// Event should be obtainable to update cache
-			eventBlock := receipt.BlockNumber.Uint64()
-			filterOpts := &bind.FilterOpts{Start: eventBlock, End: &eventBlock, Context: ctx}
-			_, found, err := t.obtainTransferFinalizedAndUpdateCache(ctx, filterOpts, event.TransferIdx)
-			if err != nil {
-				t.logger.Error("failed to obtain transfer finalized event after sending tx")
-				continue
-			}
-			if !found {
-				t.logger.Warn("transfer finalized event not found after sending tx")
-				continue
+		ticker := time.NewTicker(queuePollInterval)
+		defer ticker.Stop()
+
+		for {
+			t.recordBalance(ctx)
+			t.processPendingTransfers(ctx)
+
+			select {
+			case <-ctx.Done():
+				t.logger.Info("transactor shutting down", "chain", t.chain)
+				return
+			case _, ok := <-t.notifyChan:
+				if !ok {
+					t.logger.Info("channel to transactor was closed, transactor is exiting", "chain", t.chain)
+					return
+				}
+			case <-t.wake:
+			case <-ticker.C:
 			}
 		}
-		t.logger.Info("channel to transactor was closed, transactor is exiting", "chain", t.chain)
 	}()
 	return doneChan, nil
 }
 
+// directionName returns the name of the direction of transfers finalized on dst.
+func directionName(dst shared.Chain) string {
+	src := shared.L1
+	if dst == shared.L1 {
+		src = shared.Settlement
+	}
+	return strings.ToLower(src.String()) + "_to_" + strings.ToLower(dst.String())
+}
+
+// Health returns the health of the transactor. Its polls succeed whenever it reads the queue,
+// which it does not while waiting on finalizations in flight for a slot.
+func (t *Transactor) Health() ComponentHealth {
+	return t.health.health(t.finalizationsInFlight())
+}
+
+// resolvePendingTxes deals with the txs of the relayer account left pending by a previous
+// run according to the pending tx mode, before any new tx is sent.
+func (t *Transactor) resolvePendingTxes(ctx context.Context) {
+	var (
+		reports []shared.ReplaceReport
+		err     error
+	)
+	switch t.pendingTxMode {
+	case PendingTxAdopt:
+		reports, err = t.adoptPendingTxes(ctx)
+	case PendingTxSpeedUp:
+		var known []common.Hash
+		transfers, qErr := t.queue.PendingTransfers(ctx, t.srcChainID, 0)
+		if qErr != nil {
+			t.logger.Error("failed to obtain pending transfers", "error", qErr)
+		}
+		for _, transfer := range transfers {
+			if transfer.Status == store.TransferSubmitted {
+				known = append(known, transfer.TxHash)
+			}
+		}
+		reports, err = t.rawClient.SpeedUpPendingTxes(ctx, t.signer, known)
+	default:
+		reports, err = t.rawClient.CancelPendingTxes(ctx, t.signer)
+	}
+	for _, report := range reports {
+		t.logger.Info(
+			"pending transaction resolved",
+			"mode", t.pendingTxMode,
+			"nonce", report.Nonce,
+			"status", report.Status,
+			"report", report.String(),
+		)
+	}
+	if err != nil {
+		t.logger.Error("failed to resolve pending transactions", "mode", t.pendingTxMode, "error", err)
+	}
+}
+
+// adoptPendingTxes matches the pending txs of the relayer account against the transfers in
+// the queue. Each finalization tx of a queued transfer is handed to a worker which waits on
+// it like on any tx it sent itself. Every other pending nonce is cancelled, lowest first.
+// Adopted finalizations do not take slots, as they were in flight before the transactor started.
+func (t *Transactor) adoptPendingTxes(ctx context.Context) ([]shared.ReplaceReport, error) {
+	transfers, err := t.queue.PendingTransfers(ctx, t.srcChainID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain pending transfers: %w", err)
+	}
+	byIdx := make(map[string]store.Transfer, len(transfers))
+	var known []common.Hash
+	for _, transfer := range transfers {
+		byIdx[transfer.Event.TransferIdx.String()] = transfer
+		if transfer.Status == store.TransferSubmitted {
+			known = append(known, transfer.TxHash)
+		}
+	}
+
+	pending, err := t.rawClient.FindPendingTxes(ctx, t.signer.Address(), known)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending transactions: %w", err)
+	}
+	var unrecognized []uint64
+	for nonce := pending.From; nonce < pending.To; nonce++ {
+		tx, ok := pending.Txs[nonce]
+		if !ok {
+			unrecognized = append(unrecognized, nonce)
+			continue
+		}
+		transfer, ok := t.matchTransfer(tx, byIdx)
+		if !ok {
+			unrecognized = append(unrecognized, nonce)
+			continue
+		}
+		// A transfer is adopted once, any other tx finalizing it is cancelled.
+		delete(byIdx, transfer.Event.TransferIdx.String())
+		if err := t.adopt(ctx, transfer, tx); err != nil {
+			t.logger.Error("failed to adopt pending finalization tx",
+				"nonce", nonce, "tx_hash", tx.Hash().Hex(), "src_transfer_idx", transfer.Event.TransferIdx, "error", err)
+			unrecognized = append(unrecognized, nonce)
+		}
+	}
+
+	var reports []shared.ReplaceReport
+	for _, nonce := range unrecognized {
+		report := t.rawClient.CancelNonce(ctx, t.signer, nonce, pending.Txs[nonce])
+		reports = append(reports, report)
+		if report.Status == shared.TxReplaceFailed {
+			return reports, fmt.Errorf("failed to cancel transaction with nonce %d: %w", nonce, report.Err)
+		}
+	}
+	return reports, nil
+}
+
+// matchTransfer returns the transfer of byIdx that tx finalizes, if tx is a finalizeTransfer
+// call to the gateway matching a transfer that is not mined yet.
+func (t *Transactor) matchTransfer(tx *gethtypes.Transaction, byIdx map[string]store.Transfer) (store.Transfer, bool) {
+	if tx.To() == nil || *tx.To() != t.gatewayAddr {
+		return store.Transfer{}, false
+	}
+	call, err := shared.DecodeFinalizeTransfer(tx.Data())
+	if err != nil {
+		t.logger.Debug("pending tx is not a transfer finalization", "tx_hash", tx.Hash().Hex(), "error", err)
+		return store.Transfer{}, false
+	}
+	transfer, ok := byIdx[call.CounterpartyIdx.String()]
+	if !ok || transfer.Status == store.TransferMined {
+		return store.Transfer{}, false
+	}
+	if call.Recipient != transfer.Event.Recipient || call.Amount.Cmp(transfer.Event.Amount) != 0 {
+		t.logger.Warn("pending finalization tx does not match queued transfer",
+			"tx_hash", tx.Hash().Hex(), "src_transfer_idx", call.CounterpartyIdx,
+			"recipient", call.Recipient, "amount", call.Amount)
+		return store.Transfer{}, false
+	}
+	return transfer, true
+}
+
+// adopt records tx as the finalization tx of transfer and dispatches a worker waiting on it.
+func (t *Transactor) adopt(ctx context.Context, transfer store.Transfer, tx *gethtypes.Transaction) error {
+	opts, err := t.rawClient.AdoptTransactOpts(ctx, t.signer, t.chainID, tx)
+	if err != nil {
+		return fmt.Errorf("failed to create transact opts: %w", err)
+	}
+	if transfer.Status != store.TransferSubmitted || transfer.TxHash != tx.Hash() {
+		if err := t.queue.MarkTransferSubmitted(ctx, t.srcChainID, transfer.Event.TransferIdx, tx.Hash()); err != nil {
+			t.logger.Error("failed to mark transfer as submitted", "hash", tx.Hash().Hex(), "error", err)
+		}
+	}
+	t.logger.Info(
+		"adopted pending finalization tx",
+		"nonce", tx.Nonce(),
+		"tx_hash", tx.Hash().Hex(),
+		"src_transfer_idx", transfer.Event.TransferIdx,
+		"gas_tip", tx.GasTipCap().String(),
+		"gas_fee_cap", tx.GasFeeCap().String(),
+	)
+
+	key := transfer.Event.TransferIdx.String()
+	t.mu.Lock()
+	t.dispatched[key] = true
+	t.mu.Unlock()
+	t.workers.Add(1)
+	go func() {
+		defer t.workers.Done()
+		err := t.complete(ctx, transfer, &finalizationTx{opts: opts, adopted: tx})
+		t.handleResult(ctx, transfer, err)
+		t.mu.Lock()
+		t.dispatched[key] = false
+		t.mu.Unlock()
+	}()
+	return nil
+}
+
+// processPendingTransfers dispatches every pending transfer in the queue that is not already
+// in flight, oldest first. Nonces are assigned here, in queue order, so that finalizations are
+// included in the order the gateway expects even though up to MaxInFlight of them are sent
+// and awaited concurrently. It blocks while all slots are taken.
+func (t *Transactor) processPendingTransfers(ctx context.Context) {
+	t.mu.Lock()
+	for idx, running := range t.dispatched {
+		// The queue read below reflects the outcome of finished workers.
+		if !running {
+			delete(t.dispatched, idx)
+		}
+	}
+	t.mu.Unlock()
+
+	transfers, err := t.queue.PendingTransfers(ctx, t.srcChainID, failedRetryDelay)
+	if err != nil {
+		t.logger.Error("failed to obtain pending transfers", "error", err)
+		t.health.failed(err)
+		return
+	}
+	t.health.succeeded()
+	for _, transfer := range transfers {
+		key := transfer.Event.TransferIdx.String()
+		if t.isDispatched(key) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case t.slots <- struct{}{}:
+		}
+		// Operators may have paused the queue or changed the transfer while waiting for the slot.
+		// Only transfers with no finalization tx pending can be held, and each check is skipped
+		// for transfers an operator released from a hold of that check.
+		holdable := transfer.Status == store.TransferSeen || transfer.Status == store.TransferFailed
+		var screened *ScreeningMatch
+		if holdable && !transfer.ScreeningReleased {
+			screened = t.screener.Screen(transfer.Event.Sender, transfer.Event.Recipient)
+		}
+		// Transfers sent before are exempt from the limits. Released transfers are not checked
+		// against the limits, but count against the window limits like any other.
+		counted := holdable && transfer.SubmittedAt.IsZero()
+		limited := counted && !transfer.Released
+		t.mu.Lock()
+		_, dispatched := t.dispatched[key]
+		paused := t.pause != nil
+		var exceeded error
+		if !dispatched && !paused && screened == nil && limited {
+			exceeded = t.limiter.check(time.Now(), transfer.Event.Amount)
+		}
+		if !dispatched && !paused && screened == nil && exceeded == nil {
+			t.preparing = key
+		}
+		t.mu.Unlock()
+		if paused {
+			<-t.slots
+			t.logger.Debug("transactor queue is paused, not dispatching finalizations", "direction", t.direction)
+			return
+		}
+		if dispatched {
+			<-t.slots
+			continue
+		}
+		if screened != nil {
+			<-t.slots
+			t.holdScreened(ctx, transfer, screened)
+			continue
+		}
+		if exceeded != nil {
+			<-t.slots
+			t.hold(ctx, transfer, exceeded)
+			return
+		}
+
+		ftx, err := t.prepare(ctx, transfer)
+		t.mu.Lock()
+		t.preparing = ""
+		if ftx != nil && err == nil {
+			t.dispatched[key] = true
+			if counted {
+				t.limiter.record(time.Now(), transfer.Event.Amount)
+			}
+		}
+		t.mu.Unlock()
+		if errors.Is(err, errFinalizationBlocked) {
+			// Later transfers are blocked as well.
+			<-t.slots
+			t.logger.Warn("transfer finalization blocked, waiting for earlier transfer", "src_transfer_idx", transfer.Event.TransferIdx)
+			return
+		}
+		if err != nil || ftx == nil {
+			<-t.slots
+			t.handleResult(ctx, transfer, err)
+			continue
+		}
+
+		t.metrics.FinalizationSent()
+		t.workers.Add(1)
+		go func(transfer store.Transfer) {
+			defer t.workers.Done()
+			err := t.complete(ctx, transfer, ftx)
+			<-t.slots
+			t.handleResult(ctx, transfer, err)
+			t.mu.Lock()
+			t.dispatched[key] = false
+			t.mu.Unlock()
+		}(transfer)
+	}
+}
+
+// handleResult records a failed finalization attempt of transfer, if err is not nil. Errors
+// unrelated to the transfer itself are not counted as attempts.
+func (t *Transactor) handleResult(ctx context.Context, transfer store.Transfer, err error) {
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	switch {
+	case errors.Is(err, shared.ErrInsufficientFunds):
+		// Not the transfer's fault, retry once the operator topped up the relayer account.
+		t.logger.Error("relayer account has insufficient funds to finalize transfer",
+			"src_transfer_idx", transfer.Event.TransferIdx, "error", err)
+		return
+	case errors.Is(err, shared.ErrRateLimited):
+		t.logger.Warn("rpc rate limited while finalizing transfer, will retry",
+			"src_transfer_idx", transfer.Event.TransferIdx, "error", err)
+		return
+	}
+	t.logger.Error("failed to finalize transfer", "src_transfer_idx", transfer.Event.TransferIdx, "error", err)
+	status, err := t.queue.MarkTransferFailed(
+		ctx, t.srcChainID, transfer.Event.TransferIdx, err, maxFinalizationAttempts)
+	if err != nil {
+		t.logger.Error("failed to mark transfer as failed", "src_transfer_idx", transfer.Event.TransferIdx, "error", err)
+		return
+	}
+	t.metrics.FinalizationFailed(string(status))
+	if status == store.TransferDeadLettered {
+		t.logger.Error(
+			"transfer dead-lettered after too many failed attempts",
+			"src_transfer_idx", transfer.Event.TransferIdx,
+			"max_attempts", maxFinalizationAttempts,
+		)
+		return
+	}
+	t.logger.Warn("transfer finalization will be retried", "src_transfer_idx", transfer.Event.TransferIdx)
+}
+
+// hold takes a transfer exceeding the finalization limits out of the queue and pauses the
+// queue, so that neither it nor later transfers are finalized before an operator steps in.
+func (t *Transactor) hold(ctx context.Context, transfer store.Transfer, exceeded error) {
+	t.logger.Error(
+		"finalization limit exceeded, holding transfer and pausing queue",
+		"direction", t.direction,
+		"src_transfer_idx", transfer.Event.TransferIdx,
+		"amount", transfer.Event.Amount,
+		"error", exceeded,
+	)
+	reason := "finalization limit exceeded: " + exceeded.Error()
+	if err := t.queue.MarkTransferHeld(ctx, t.srcChainID, transfer.Event.TransferIdx, reason); err != nil {
+		t.logger.Error("failed to mark transfer as held", "src_transfer_idx", transfer.Event.TransferIdx, "error", err)
+	} else {
+		t.metrics.FinalizationFailed(string(store.TransferHeld))
+	}
+	if err := t.Pause(ctx, fmt.Sprintf("transfer %s held: %s", transfer.Event.TransferIdx, reason)); err != nil {
+		t.logger.Error("failed to pause queue", "direction", t.direction, "error", err)
+	}
+}
+
+// holdScreened takes a transfer failing screening out of the queue and records it in the
+// screening audit log. As the gateway finalizes transfers in order, later transfers wait on it.
+// If holding fails, the transfer stays in the queue and is screened again on the next read,
+// unless it was taken out of the queue concurrently.
+func (t *Transactor) holdScreened(ctx context.Context, transfer store.Transfer, match *ScreeningMatch) {
+	t.logger.Warn(
+		"transfer failed screening, holding it",
+		"direction", t.direction,
+		"src_transfer_idx", transfer.Event.TransferIdx,
+		"sender", transfer.Event.Sender,
+		"recipient", transfer.Event.Recipient,
+		"amount", transfer.Event.Amount,
+		"match", match.Error(),
+	)
+	err := t.queue.HoldScreenedTransfer(ctx, store.ScreeningHold{
+		SrcChainID:  t.srcChainID,
+		TransferIdx: transfer.Event.TransferIdx,
+		Sender:      transfer.Event.Sender,
+		Recipient:   transfer.Event.Recipient,
+		Amount:      transfer.Event.Amount,
+		Party:       match.Party,
+		Address:     match.Address,
+		List:        match.List,
+		File:        match.File,
+		Reason:      "failed screening: " + match.Error(),
+		HeldAt:      time.Now(),
+	})
+	if errors.Is(err, store.ErrInvalidTransition) {
+		t.logger.Warn("screened transfer changed concurrently, not holding it",
+			"src_transfer_idx", transfer.Event.TransferIdx, "error", err)
+		return
+	}
+	if err != nil {
+		t.logger.Error("failed to hold screened transfer", "src_transfer_idx", transfer.Event.TransferIdx, "error", err)
+		return
+	}
+	t.metrics.FinalizationFailed(string(store.TransferHeld))
+}
+
+// prepare checks whether the transfer still needs to be finalized and if so, returns its
+// finalization tx holding the next nonce and an estimated gas limit. If the transfer was
+// already finalized, it is marked confirmed once its finalization is final and nil is
+// returned. If its finalization would revert, it is quarantined and nil is returned.
+func (t *Transactor) prepare(ctx context.Context, transfer store.Transfer) (*finalizationTx, error) {
+	event := transfer.Event
+	t.logger.Debug(
+		"submitting transfer finalization tx",
+		"dst_chain", t.chain,
+		"src_chain", event.Chain,
+		"recipient", event.Recipient,
+		"amount", event.Amount,
+		"src_transfer_idx", event.TransferIdx,
+		"status", transfer.Status,
+		"attempts", transfer.Attempts,
+	)
+	finalized, err := t.transferAlreadyFinalized(ctx, event.TransferIdx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if transfer already finalized: %w", err)
+	}
+	if finalized {
+		return nil, t.confirm(ctx, transfer)
+	}
+	done, dependent, err := t.simulate(ctx, transfer)
+	if err != nil || done {
+		return nil, err
+	}
+	opts, err := t.rawClient.CreateTransactOpts(ctx, t.signer, t.chainID, transfer.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transact opts for transfer finalization tx: %w", err)
+	}
+	gasEstimate, err := t.rawClient.EstimateGasLimit(ctx, opts, t.buildFinalizeTransfer(event), dependent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas limit of transfer finalization tx: %w", err)
+	}
+	return &finalizationTx{opts: opts, gasEstimate: gasEstimate}, nil
+}
+
+// simulate runs the finalization of transfer with eth_call and quarantines the transfer if it
+// reverts for any reason other than the transfer being finalized already or an earlier
+// transfer not being finalized yet, which is expected while finalizations are in flight.
+// It reports whether the transfer needs no finalization tx, and whether the finalization
+// reverted only because it depends on finalizations in flight.
+func (t *Transactor) simulate(ctx context.Context, transfer store.Transfer) (bool, bool, error) {
+	event := transfer.Event
+	err := t.rawClient.Simulate(ctx, t.signer.Address(), t.buildFinalizeTransfer(event))
+	var revertErr *shared.CallRevertError
+	if !errors.As(err, &revertErr) {
+		return false, false, err
+	}
+
+	next, err := t.gatewayCaller.TransferFinalizedIdx(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return false, false, fmt.Errorf("failed to get transfer finalized idx: %w", err)
+	}
+	switch event.TransferIdx.Cmp(next) {
+	case -1:
+		// The gateway finalized the transfer already, but not since the block cached as most
+		// recently finalized, e.g. by an earlier tx of a requeued or failed transfer.
+		return true, false, t.confirmFinalized(ctx, transfer)
+	case 1:
+		if t.finalizationsInFlight() {
+			t.logger.Debug("transfer finalization simulation reverted while finalizations are in flight",
+				"src_transfer_idx", event.TransferIdx, "next_transfer_idx", next, "reason", revertErr.Reason)
+			return false, true, nil
+		}
+		return false, false, errFinalizationBlocked
+	}
+
+	t.logger.Error(
+		"transfer quarantined, its finalization would revert",
+		"src_transfer_idx", event.TransferIdx,
+		"recipient", event.Recipient,
+		"amount", event.Amount,
+		"reason", revertErr.Reason,
+	)
+	if err := t.queue.MarkTransferQuarantined(ctx, t.srcChainID, event.TransferIdx, revertErr); err != nil {
+		return false, false, fmt.Errorf("failed to quarantine transfer: %w", err)
+	}
+	return true, false, nil
+}
+
+// confirmFinalized confirms a transfer the gateway finalized already by searching all blocks
+// for its TransferFinalized event. If the event is not final yet, the transfer is left as is
+// to be confirmed by a later attempt.
+func (t *Transactor) confirmFinalized(ctx context.Context, transfer store.Transfer) error {
+	finalized, err := t.transferFinalizedSince(ctx, 0, transfer.Event.TransferIdx)
+	if err != nil {
+		return fmt.Errorf("failed to check if transfer already finalized: %w", err)
+	}
+	if !finalized {
+		t.logger.Info("transfer finalized in a block that is not final yet, waiting for finality",
+			"src_transfer_idx", transfer.Event.TransferIdx, "policy", t.chainCfg.Finality)
+		return nil
+	}
+	return t.confirm(ctx, transfer)
+}
+
+func (t *Transactor) isDispatched(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, dispatched := t.dispatched[key]
+	return dispatched
+}
+
+func (t *Transactor) finalizationsInFlight() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, running := range t.dispatched {
+		if running {
+			return true
+		}
+	}
+	return false
+}
+
+// complete sends the prepared finalization tx of the transfer and drives the transfer to
+// the confirmed state.
+func (t *Transactor) complete(ctx context.Context, transfer store.Transfer, ftx *finalizationTx) error {
+	event := transfer.Event
+	receipt, err := t.sendFinalizeTransfer(ctx, ftx, event)
+	if err != nil {
+		return fmt.Errorf("failed to send transfer finalization tx: %w", err)
+	}
+	if err := t.queue.MarkTransferMined(ctx, t.srcChainID, event.TransferIdx, receipt.TxHash); err != nil {
+		return fmt.Errorf("failed to mark transfer as mined: %w", err)
+	}
+	receipt, err = t.waitFinalized(ctx, receipt.TxHash)
+	if err != nil {
+		return fmt.Errorf("failed to wait for finality of transfer finalization tx: %w", err)
+	}
+	// Event should be obtainable to update cache
+	eventBlock := receipt.BlockNumber.Uint64()
+	filterOpts := &bind.FilterOpts{Start: eventBlock, End: &eventBlock, Context: ctx}
+	_, found, err := t.obtainTransferFinalizedAndUpdateCache(ctx, filterOpts, event.TransferIdx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain transfer finalized event after sending tx: %w", err)
+	}
+	if !found {
+		return errors.New("transfer finalized event not found after sending tx")
+	}
+	return t.confirm(ctx, transfer)
+}
+
+// confirm marks transfer as confirmed and records its relay latency.
+func (t *Transactor) confirm(ctx context.Context, transfer store.Transfer) error {
+	if err := t.queue.MarkTransferConfirmed(ctx, t.srcChainID, transfer.Event.TransferIdx); err != nil {
+		return err
+	}
+	t.metrics.FinalizationConfirmed(transfer.CreatedAt)
+	return nil
+}
+
+// recordBalance records the balance of the relayer account, if metrics are recorded.
+func (t *Transactor) recordBalance(ctx context.Context) {
+	if t.metrics == nil {
+		return
+	}
+	balance, err := t.rawClient.BalanceAt(ctx, t.signer.Address(), nil)
+	if err != nil {
+		t.logger.Warn("failed to obtain relayer balance for metrics", "error", err, "chain", t.chain)
+		return
+	}
+	t.metrics.Balance(balance)
+}
+
+// transferAlreadyFinalized reports whether the gateway emitted a TransferFinalized event for
+// transferIdx in a block that is final under the finality policy. Finalizations in blocks that
+// are not final yet are not reported, as a reorg may still drop them.
 func (t *Transactor) transferAlreadyFinalized(
 	ctx context.Context,
 	transferIdx *big.Int,
 ) (bool, error) {
-	const maxBlockRange = 40000
+	t.mu.Lock()
+	startBlock := t.mostRecentFinalized.opts.Start
+	t.mu.Unlock()
+	return t.transferFinalizedSince(ctx, startBlock, transferIdx)
+}
+
+// transferFinalizedSince is transferAlreadyFinalized searching from startBlock.
+func (t *Transactor) transferFinalizedSince(
+	ctx context.Context,
+	startBlock uint64,
+	transferIdx *big.Int,
+) (bool, error) {
+	maxBlockRange := t.chainCfg.BatchSize
 	var interEndBlock uint64 // Intermediate end block for each range
 
-	currentBlock, err := t.rawClient.BlockNumber(ctx)
+	currentBlock, err := t.chainCfg.Finality.FinalizedBlockNum(ctx, t.rawClient)
 	if err != nil {
-		return false, fmt.Errorf("failed to get current block number: %w", err)
+		return false, fmt.Errorf("failed to get finalized block number: %w", err)
 	}
 
-	startBlock := t.mostRecentFinalized.opts.Start
-
 	for start := startBlock; start <= currentBlock; start = interEndBlock + 1 {
 		interEndBlock = start + maxBlockRange
 		if interEndBlock > currentBlock {
@@ -181,18 +846,27 @@ func (t *Transactor) transferAlreadyFinalized(
 	return false, nil
 }
 
+// buildFinalizeTransfer returns a callback creating the finalization tx of event. It has
+// no side effects besides sending the tx, which does not happen if opts has NoSend set.
+func (t *Transactor) buildFinalizeTransfer(event shared.TransferInitiatedEvent) shared.TxSubmitFunc {
+	return func(ctx context.Context, opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return t.gatewayTransactor.FinalizeTransfer(opts, event.Recipient, event.Amount, event.TransferIdx)
+	}
+}
+
 func (t *Transactor) sendFinalizeTransfer(
 	ctx context.Context,
-	opts *bind.TransactOpts,
+	ftx *finalizationTx,
 	event shared.TransferInitiatedEvent,
 ) (*gethtypes.Receipt, error) {
 
 	// Capture event params in closure and define tx submission callback
+	buildFinalizeTransfer := t.buildFinalizeTransfer(event)
 	submitFinalizeTransfer := func(
 		ctx context.Context,
 		opts *bind.TransactOpts,
 	) (*gethtypes.Transaction, error) {
-		tx, err := t.gatewayTransactor.FinalizeTransfer(opts, event.Recipient, event.Amount, event.TransferIdx)
+		tx, err := buildFinalizeTransfer(ctx, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to send finalize transfer tx: %w", err)
 		}
@@ -203,19 +877,84 @@ func (t *Transactor) sendFinalizeTransfer(
 			"amount", event.Amount,
 			"src_transfer_idx", event.TransferIdx,
 		)
+		// The tx is already broadcast, a failure to record it must not fail the submission.
+		if err := t.queue.MarkTransferSubmitted(ctx, t.srcChainID, event.TransferIdx, tx.Hash()); err != nil {
+			t.logger.Error("failed to mark transfer as submitted", "hash", tx.Hash().Hex(), "error", err)
+		}
 		return tx, nil
 	}
 
-	receipt, err := t.rawClient.WaitMinedWithRetry(ctx, opts, submitFinalizeTransfer)
+	var mined *shared.MinedTx
+	var err error
+	if ftx.adopted != nil {
+		mined, err = t.rawClient.WaitAdoptedWithRetry(ctx, ftx.opts, ftx.adopted, submitFinalizeTransfer)
+	} else {
+		mined, err = t.rawClient.WaitMinedWithRetry(ctx, ftx.opts, submitFinalizeTransfer)
+	}
+	var revertErr *shared.RevertError
+	if errors.As(err, &revertErr) {
+		t.logger.Error(
+			"transfer finalization tx reverted",
+			"tx_hash", revertErr.Receipt.TxHash.Hex(),
+			"block_number", revertErr.Receipt.BlockNumber,
+			"reason", revertErr.Reason,
+			"src_transfer_idx", event.TransferIdx,
+		)
+		return nil, fmt.Errorf("transfer finalization tx reverted: %w", err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to wait for finalize transfer tx to be mined: %w", err)
 	}
+	receipt := mined.Receipt
 	includedInBlock := receipt.BlockNumber.Uint64()
-	t.logger.Info("finalizeTransfer tx included in block", "block_number", includedInBlock, "chain", t.chain)
+	t.logger.Info(
+		"finalizeTransfer tx included in block",
+		"block_number", includedInBlock,
+		"chain", t.chain,
+		"gas_estimate", ftx.gasEstimate,
+		"gas_limit", ftx.opts.GasLimit,
+		"gas_used", receipt.GasUsed,
+		"tx_hash", receipt.TxHash.Hex(),
+		"attempt", mined.Attempt.Attempt,
+	)
 
 	return receipt, nil
 }
 
+// waitFinalized blocks until the tx with txHash is included in a block that is final under
+// the finality policy and returns its receipt. The receipt is re-obtained on every check,
+// so a tx that was reorged out results in an error.
+func (t *Transactor) waitFinalized(ctx context.Context, txHash common.Hash) (*gethtypes.Receipt, error) {
+	ticker := time.NewTicker(finalityPollInterval)
+	defer ticker.Stop()
+
+	for {
+		finalizedBlockNum, err := t.chainCfg.Finality.FinalizedBlockNum(ctx, t.rawClient)
+		if err != nil {
+			return nil, err
+		}
+		receipt, err := t.rawClient.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain receipt of tx %s: %w", txHash.Hex(), err)
+		}
+		if receipt.BlockNumber.Uint64() <= finalizedBlockNum {
+			return receipt, nil
+		}
+		t.logger.Debug(
+			"waiting for finalization tx to be final",
+			"hash", txHash.Hex(),
+			"block_number", receipt.BlockNumber,
+			"finalized_block_number", finalizedBlockNum,
+			"policy", t.chainCfg.Finality,
+		)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 func (t *Transactor) obtainTransferFinalizedAndUpdateCache(
 	ctx context.Context,
 	opts *bind.FilterOpts,
@@ -226,8 +965,13 @@ func (t *Transactor) obtainTransferFinalizedAndUpdateCache(
 		return shared.TransferFinalizedEvent{}, false, fmt.Errorf("failed to obtain transfer finalized event: %w", err)
 	}
 	if found {
-		t.mostRecentFinalized = mostRecentFinalized{event, *opts}
-		t.logger.Debug("mostRecentFinalized cache updated", "new", fmt.Sprintf("%+v", t.mostRecentFinalized))
+		t.mu.Lock()
+		// Workers may find events out of order, only move the cache forward.
+		if opts.Start >= t.mostRecentFinalized.opts.Start {
+			t.mostRecentFinalized = mostRecentFinalized{event, *opts}
+			t.logger.Debug("mostRecentFinalized cache updated", "new", fmt.Sprintf("%+v", t.mostRecentFinalized))
+		}
+		t.mu.Unlock()
 	}
 	return event, found, nil
 }
@@ -0,0 +1,54 @@
+package shared
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	l1g "github.com/primevprotocol/contracts-abi/clients/L1Gateway"
+)
+
+// ErrNotFinalizeTransfer is returned for calldata that is not a finalizeTransfer call.
+var ErrNotFinalizeTransfer = errors.New("not a finalizeTransfer call")
+
+// FinalizeTransferCall holds the arguments of a finalizeTransfer call to either gateway.
+type FinalizeTransferCall struct {
+	Recipient       common.Address
+	Amount          *big.Int
+	CounterpartyIdx *big.Int
+}
+
+// Both gateways declare finalizeTransfer with the same signature.
+var gatewayABI = sync.OnceValues(l1g.L1gatewayMetaData.GetAbi)
+
+// DecodeFinalizeTransfer decodes the calldata of a finalizeTransfer call to either gateway.
+func DecodeFinalizeTransfer(data []byte) (FinalizeTransferCall, error) {
+	parsed, err := gatewayABI()
+	if err != nil {
+		return FinalizeTransferCall{}, fmt.Errorf("failed to parse gateway abi: %w", err)
+	}
+	method, ok := parsed.Methods["finalizeTransfer"]
+	if !ok {
+		return FinalizeTransferCall{}, errors.New("gateway abi has no finalizeTransfer method")
+	}
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return FinalizeTransferCall{}, ErrNotFinalizeTransfer
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return FinalizeTransferCall{}, fmt.Errorf("failed to unpack finalizeTransfer args: %w", err)
+	}
+	if len(args) != 3 {
+		return FinalizeTransferCall{}, fmt.Errorf("unexpected number of finalizeTransfer args: %d", len(args))
+	}
+	recipient, ok1 := args[0].(common.Address)
+	amount, ok2 := args[1].(*big.Int)
+	counterpartyIdx, ok3 := args[2].(*big.Int)
+	if !ok1 || !ok2 || !ok3 {
+		return FinalizeTransferCall{}, errors.New("unexpected finalizeTransfer arg types")
+	}
+	return FinalizeTransferCall{Recipient: recipient, Amount: amount, CounterpartyIdx: counterpartyIdx}, nil
+}
@@ -0,0 +1,257 @@
+package shared
+
+import (
+	_ "embed"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default_chains.yml
+var defaultChainRegistry []byte
+
+const (
+	defaultPollInterval = 5 * time.Second
+	// Most nodes limit query ranges so logs are fetched in 40k increments by default.
+	defaultBatchSize     = 40000
+	defaultGasLimit      = 3000000
+	defaultGasMultiplier = 1.2
+	defaultBumpPercent   = 10
+	defaultBumpInterval  = 60 * time.Second
+	defaultMaxAttempts   = 10
+	defaultMaxInFlight   = 4
+)
+
+// GasConfig holds the transaction gas settings of a chain.
+type GasConfig struct {
+	// Limit is the gas limit ceiling. Txs estimated to need more gas are not sent.
+	Limit uint64 `yaml:"limit"`
+	// EstimateMultiplier is the safety margin applied to gas estimates, e.g. 1.2 for 20%.
+	EstimateMultiplier float64 `yaml:"estimate_multiplier"`
+	// BumpPercent is the percentage by which fees are increased for a replacement tx.
+	BumpPercent uint64 `yaml:"bump_percent"`
+	// BumpInterval is how long a tx may stay pending before it is replaced.
+	BumpInterval time.Duration `yaml:"bump_interval"`
+	// MaxAttempts is the number of times a tx is (re)submitted before giving up.
+	MaxAttempts int `yaml:"max_attempts"`
+	// Strategy selects the GasStrategy, one of "node", "fee_history" or "fixed".
+	Strategy string `yaml:"strategy"`
+	// MaxFeeCapGwei is the absolute max fee per gas of any tx, unlimited if 0.
+	MaxFeeCapGwei float64          `yaml:"max_fee_cap_gwei"`
+	FeeHistory    FeeHistoryConfig `yaml:"fee_history"`
+	Fixed         FixedFeesConfig  `yaml:"fixed"`
+	Aggressive    AggressiveConfig `yaml:"aggressive"`
+}
+
+// WithDefaults returns a copy of the config with every unset field set to its default.
+func (g GasConfig) WithDefaults() GasConfig {
+	if g.Limit == 0 {
+		g.Limit = defaultGasLimit
+	}
+	if g.EstimateMultiplier == 0 {
+		g.EstimateMultiplier = defaultGasMultiplier
+	}
+	if g.BumpPercent == 0 {
+		g.BumpPercent = defaultBumpPercent
+	}
+	if g.BumpInterval == 0 {
+		g.BumpInterval = defaultBumpInterval
+	}
+	if g.MaxAttempts == 0 {
+		g.MaxAttempts = defaultMaxAttempts
+	}
+	if g.Strategy == "" {
+		g.Strategy = GasStrategyNode
+	}
+	if g.FeeHistory.Blocks == 0 {
+		g.FeeHistory.Blocks = defaultFeeHistoryBlocks
+	}
+	if g.FeeHistory.Percentile == 0 {
+		g.FeeHistory.Percentile = defaultFeeHistoryPercentile
+	}
+	if g.Aggressive.TipPercent == 0 {
+		g.Aggressive.TipPercent = defaultAggressiveTipPercent
+	}
+	return g
+}
+
+// Validate checks that the settings are consistent, defaults must have been applied.
+func (g GasConfig) Validate() error {
+	if g.EstimateMultiplier < 1 {
+		return fmt.Errorf("gas estimate_multiplier must be at least 1, got %v", g.EstimateMultiplier)
+	}
+	if g.BumpPercent < minReplacementBumpPercent {
+		return fmt.Errorf("gas bump_percent must be at least %d for replacements to be accepted, got %d",
+			minReplacementBumpPercent, g.BumpPercent)
+	}
+	if g.MaxFeeCapGwei < 0 {
+		return fmt.Errorf("gas max_fee_cap_gwei must not be negative, got %v", g.MaxFeeCapGwei)
+	}
+	switch g.Strategy {
+	case GasStrategyNode:
+	case GasStrategyFeeHistory:
+		if g.FeeHistory.Percentile < 0 || g.FeeHistory.Percentile > 100 {
+			return fmt.Errorf("gas fee_history percentile must be in [0, 100], got %v", g.FeeHistory.Percentile)
+		}
+	case GasStrategyFixed:
+		if g.Fixed.TipCapGwei <= 0 || g.Fixed.FeeCapGwei < g.Fixed.TipCapGwei {
+			return fmt.Errorf("gas fixed fees require 0 < tip_cap_gwei <= fee_cap_gwei")
+		}
+	default:
+		return fmt.Errorf("unknown gas strategy: %q", g.Strategy)
+	}
+	return nil
+}
+
+// ChainConfig describes a chain the bridge operates on.
+type ChainConfig struct {
+	ChainID      uint64
+	Name         string
+	Role         Chain
+	Finality     FinalityPolicy
+	PollInterval time.Duration
+	// BatchSize is the maximum block range of a single log query.
+	BatchSize uint64
+	// MaxInFlight is the maximum number of finalization txs the relayer has pending at once.
+	MaxInFlight int
+	Gas         GasConfig
+}
+
+// chainEntry is the YAML representation of a ChainConfig.
+type chainEntry struct {
+	ChainID      uint64        `yaml:"chain_id"`
+	Name         string        `yaml:"name"`
+	Role         string        `yaml:"role"`
+	Finality     string        `yaml:"finality"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    uint64        `yaml:"batch_size"`
+	MaxInFlight  int           `yaml:"max_in_flight"`
+	Gas          GasConfig     `yaml:"gas"`
+}
+
+// ChainRegistry maps chain ids to their configuration.
+type ChainRegistry struct {
+	chains map[uint64]ChainConfig
+}
+
+// LoadChainRegistry loads the registry from the YAML file at path,
+// or the built-in registry if path is empty.
+func LoadChainRegistry(path string) (*ChainRegistry, error) {
+	if path == "" {
+		return ParseChainRegistry(defaultChainRegistry)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain registry: %w", err)
+	}
+	return ParseChainRegistry(data)
+}
+
+// ParseChainRegistry parses a YAML chain registry and validates every entry.
+func ParseChainRegistry(data []byte) (*ChainRegistry, error) {
+	var file struct {
+		Chains []chainEntry `yaml:"chains"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse chain registry: %w", err)
+	}
+	r := &ChainRegistry{chains: make(map[uint64]ChainConfig, len(file.Chains))}
+	for i, e := range file.Chains {
+		if e.ChainID == 0 {
+			return nil, fmt.Errorf("chain at index %d: chain_id is required", i)
+		}
+		if _, ok := r.chains[e.ChainID]; ok {
+			return nil, fmt.Errorf("chain %d: duplicate chain_id", e.ChainID)
+		}
+		role, err := ParseChain(e.Role)
+		if err != nil {
+			return nil, fmt.Errorf("chain %d: %w", e.ChainID, err)
+		}
+		finality, err := ParseFinalityPolicy(e.Finality)
+		if err != nil {
+			return nil, fmt.Errorf("chain %d: %w", e.ChainID, err)
+		}
+		c := ChainConfig{
+			ChainID:      e.ChainID,
+			Name:         e.Name,
+			Role:         role,
+			Finality:     finality,
+			PollInterval: e.PollInterval,
+			BatchSize:    e.BatchSize,
+			MaxInFlight:  e.MaxInFlight,
+			Gas:          e.Gas,
+		}
+		if c.Name == "" {
+			c.Name = fmt.Sprint(c.ChainID)
+		}
+		if c.PollInterval == 0 {
+			c.PollInterval = defaultPollInterval
+		}
+		if c.BatchSize == 0 {
+			c.BatchSize = defaultBatchSize
+		}
+		if c.MaxInFlight <= 0 {
+			c.MaxInFlight = defaultMaxInFlight
+		}
+		c.Gas = c.Gas.WithDefaults()
+		if err := c.Gas.Validate(); err != nil {
+			return nil, fmt.Errorf("chain %d: %w", e.ChainID, err)
+		}
+		r.chains[c.ChainID] = c
+	}
+	return r, nil
+}
+
+// Lookup returns the configuration of the chain with chainID.
+func (r *ChainRegistry) Lookup(chainID *big.Int) (ChainConfig, error) {
+	if !chainID.IsUint64() {
+		return ChainConfig{}, fmt.Errorf("unsupported chain id: %s", chainID)
+	}
+	c, ok := r.chains[chainID.Uint64()]
+	if !ok {
+		return ChainConfig{}, fmt.Errorf("unsupported chain id: %s, not in chain registry", chainID)
+	}
+	return c, nil
+}
+
+// LookupRole returns the configuration of the chain with chainID,
+// failing if the chain does not have the given role.
+func (r *ChainRegistry) LookupRole(chainID *big.Int, role Chain) (ChainConfig, error) {
+	c, err := r.Lookup(chainID)
+	if err != nil {
+		return ChainConfig{}, err
+	}
+	if c.Role != role {
+		return ChainConfig{}, fmt.Errorf("chain %s (%s) is registered as %s, not %s", chainID, c.Name, c.Role, role)
+	}
+	return c, nil
+}
+
+// WithGasStrategy returns a copy of the registry in which every chain uses strategy.
+func (r *ChainRegistry) WithGasStrategy(strategy string) (*ChainRegistry, error) {
+	cp := &ChainRegistry{chains: make(map[uint64]ChainConfig, len(r.chains))}
+	for id, c := range r.chains {
+		c.Gas.Strategy = strategy
+		if err := c.Gas.Validate(); err != nil {
+			return nil, fmt.Errorf("chain %d: %w", id, err)
+		}
+		cp.chains[id] = c
+	}
+	return cp, nil
+}
+
+// ParseChain parses a chain role, either "l1" or "settlement".
+func ParseChain(s string) (Chain, error) {
+	switch strings.ToLower(s) {
+	case "l1":
+		return L1, nil
+	case "settlement":
+		return Settlement, nil
+	default:
+		return 0, fmt.Errorf("unknown chain role: %q", s)
+	}
+}
@@ -2,26 +2,69 @@ package shared
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
-	"strings"
+	"sync"
 	"time"
 
+	"standard-bridge/pkg/metrics"
+
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
 )
 
+// submitBackoff is how long to wait before resubmitting a tx the node throttled.
+const submitBackoff = 5 * time.Second
+
 type ETHClient struct {
-	logger *slog.Logger
-	client *ethclient.Client
+	logger   *slog.Logger
+	client   Backend
+	gas      GasConfig
+	strategy GasStrategy
+	noncesMu sync.Mutex
+	nonces   map[common.Address]*NonceManager
+	// estimates holds the last gas estimate per contract method, keyed by
+	// callee address and selector, see EstimateGasLimit.
+	estimatesMu sync.Mutex
+	estimates   map[string]uint64
+	// metrics records included txs and fee bumps, nothing is recorded if nil.
+	metrics *metrics.ChainMetrics
+}
+
+// NewETHClient returns an ETHClient sending txs with the given gas settings.
+// Unset gas settings fall back to their defaults.
+func NewETHClient(logger *slog.Logger, client Backend, gas GasConfig) *ETHClient {
+	gas = gas.WithDefaults()
+	return &ETHClient{
+		logger:    logger,
+		client:    client,
+		gas:       gas,
+		strategy:  NewGasStrategy(gas, client),
+		nonces:    make(map[common.Address]*NonceManager),
+		estimates: make(map[string]uint64),
+	}
 }
 
-func NewETHClient(logger *slog.Logger, client *ethclient.Client) *ETHClient {
-	return &ETHClient{logger: logger, client: client}
+// SetMetrics has the client record the txs it waits on in m. It must be called before any tx is sent.
+func (c *ETHClient) SetMetrics(m *metrics.ChainMetrics) {
+	c.metrics = m
+}
+
+// nonceManager returns the nonce manager of account, creating it on first use.
+func (c *ETHClient) nonceManager(account common.Address) *NonceManager {
+	c.noncesMu.Lock()
+	defer c.noncesMu.Unlock()
+	m, ok := c.nonces[account]
+	if !ok {
+		m = NewNonceManager(c.logger.With("account", account.Hex()), c.client, account)
+		c.nonces[account] = m
+	}
+	return m
 }
 
 func (c *ETHClient) ChainID(ctx context.Context) (*big.Int, error) {
@@ -32,49 +75,117 @@ func (c *ETHClient) BlockNumber(ctx context.Context) (uint64, error) {
 	return c.client.BlockNumber(ctx)
 }
 
+func (c *ETHClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return c.client.HeaderByNumber(ctx, number)
+}
+
+func (c *ETHClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return c.client.BalanceAt(ctx, account, blockNumber)
+}
+
+func (c *ETHClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return c.client.TransactionReceipt(ctx, txHash)
+}
+
+// CreateTransactOpts returns the opts of a new tx acting on a request made at requestedAt,
+// which the gas strategy may use to raise the fees of txs for old requests.
 func (c *ETHClient) CreateTransactOpts(
 	ctx context.Context,
-	privateKey *ecdsa.PrivateKey,
+	signer Signer,
 	srcChainID *big.Int,
+	requestedAt time.Time,
 ) (*bind.TransactOpts, error) {
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, srcChainID)
+	auth, err := NewTransactOpts(ctx, signer, srcChainID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transactor: %w", err)
 	}
 
-	fromAddress := auth.From
-	nonce, err := c.client.PendingNonceAt(ctx, fromAddress)
+	fees, err := c.strategy.SuggestFees(ctx, requestedAt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pending nonce: %w", err)
+		return nil, fmt.Errorf("failed to suggest fees: %w", err)
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
 
-	gasTip, gasPrice, err := c.SuggestGasTipCapAndPrice(ctx)
+	// The nonce is taken last so that it is not held if anything above fails.
+	// It is released by WaitMinedWithRetry.
+	nonce, err := c.nonceManager(auth.From).Next(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to suggest gas tip cap and price: %w", err)
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
 	}
+	auth.Nonce = new(big.Int).SetUint64(nonce)
 
-	auth.GasFeeCap = gasPrice
-	auth.GasTipCap = gasTip
-	auth.GasLimit = uint64(3000000)
+	auth.GasFeeCap = fees.FeeCap
+	auth.GasTipCap = fees.TipCap
+	auth.GasLimit = c.gas.Limit // Lowered by EstimateGasLimit
 	return auth, nil
 }
 
-func (c *ETHClient) SuggestGasTipCapAndPrice(ctx context.Context) (*big.Int, *big.Int, error) {
-	// Returns priority fee per gas
-	gasTip, err := c.client.SuggestGasTipCap(ctx)
+// EstimateGasLimit sets opts.GasLimit to the gas estimate of the tx built by buildTx, multiplied
+// by the safety multiplier and capped at the gas limit ceiling, and returns the estimate.
+// buildTx is called with NoSend set and must not have side effects. If the estimate fails,
+// or exceeds the ceiling, the nonce of opts is released and an error is returned.
+//
+// dependent is set by callers that know the call reverts only because it depends on the
+// effects of other txs of the account in flight, e.g. transfers the gateway finalizes in order.
+// In that case a reverting estimate is replaced by the last estimate of the same contract
+// method. Any other revert fails the estimate, so that txs known to revert are not sent.
+func (c *ETHClient) EstimateGasLimit(
+	ctx context.Context,
+	opts *bind.TransactOpts,
+	buildTx TxSubmitFunc,
+	dependent bool,
+) (estimate uint64, err error) {
+	defer func() {
+		if err != nil {
+			c.nonceManager(opts.From).Release(opts.Nonce.Uint64(), false)
+		}
+	}()
+
+	buildOpts := *opts
+	buildOpts.NoSend = true
+	tx, err := buildTx(ctx, &buildOpts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get gas tip cap: %w", err)
+		return 0, fmt.Errorf("failed to build tx for gas estimation: %w", err)
 	}
-	// Returns priority fee per gas + base fee per gas
-	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	key := tx.To().Hex()
+	if len(tx.Data()) >= 4 {
+		key += common.Bytes2Hex(tx.Data()[:4])
+	}
+
+	estimate, err = c.client.EstimateGas(ctx, ethereum.CallMsg{
+		From:      opts.From,
+		To:        tx.To(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get gas price: %w", err)
+		c.estimatesMu.Lock()
+		last, ok := c.estimates[key]
+		c.estimatesMu.Unlock()
+		err = ClassifyError(err)
+		if !dependent || !ok || !errors.Is(err, ErrExecutionReverted) || c.nonceManager(opts.From).InFlight() < 2 {
+			return 0, fmt.Errorf("failed to estimate gas: %w", err)
+		}
+		c.logger.Debug("gas estimation reverted while txs are in flight, using last estimate", "estimate", last, "error", err)
+		estimate = last
+	} else {
+		c.estimatesMu.Lock()
+		c.estimates[key] = estimate
+		c.estimatesMu.Unlock()
+	}
+
+	if estimate > c.gas.Limit {
+		return 0, fmt.Errorf("gas estimate %d exceeds gas limit ceiling %d", estimate, c.gas.Limit)
 	}
-	return gasTip, gasPrice, nil
+	opts.GasLimit = min(uint64(float64(estimate)*c.gas.EstimateMultiplier), c.gas.Limit)
+	c.logger.Debug("gas limit estimated", "estimate", estimate, "gas_limit", opts.GasLimit)
+	return estimate, nil
 }
 
-// TODO: Unit tests
+// BoostTipForTransactOpts raises the fees of opts as decided by the gas strategy so
+// that a tx sent with them replaces the one sent with the previous fees. It returns
+// ErrMaxFeeCapReached, leaving opts unchanged, if the fees cannot be raised any further.
 func (c *ETHClient) BoostTipForTransactOpts(
 	ctx context.Context,
 	opts *bind.TransactOpts,
@@ -86,55 +197,32 @@ func (c *ETHClient) BoostTipForTransactOpts(
 		"base_fee", new(big.Int).Sub(opts.GasFeeCap, opts.GasTipCap).String(),
 	)
 
-	newGasTip, newFeeCap, err := c.SuggestGasTipCapAndPrice(ctx)
+	fees, err := c.strategy.BumpFees(ctx, Fees{TipCap: opts.GasTipCap, FeeCap: opts.GasFeeCap})
 	if err != nil {
-		return fmt.Errorf("failed to suggest gas tip cap and price: %w", err)
-	}
-
-	newBaseFee := new(big.Int).Sub(newFeeCap, newGasTip)
-	if newBaseFee.Cmp(big.NewInt(0)) == -1 {
-		return fmt.Errorf("new base fee cannot be negative: %s", newBaseFee.String())
-	}
-
-	prevBaseFee := new(big.Int).Sub(opts.GasFeeCap, opts.GasTipCap)
-	if prevBaseFee.Cmp(big.NewInt(0)) == -1 {
-		return fmt.Errorf("base fee cannot be negative: %s", prevBaseFee.String())
-	}
-
-	var maxBaseFee *big.Int
-	if newBaseFee.Cmp(prevBaseFee) == 1 {
-		maxBaseFee = newBaseFee
-	} else {
-		maxBaseFee = prevBaseFee
+		return fmt.Errorf("failed to bump fees: %w", err)
 	}
+	opts.GasTipCap = fees.TipCap
+	opts.GasFeeCap = fees.FeeCap
 
-	var maxGasTip *big.Int
-	if newGasTip.Cmp(opts.GasTipCap) == 1 {
-		maxGasTip = newGasTip
-	} else {
-		maxGasTip = opts.GasTipCap
-	}
-
-	boostedTip := new(big.Int).Add(maxGasTip, new(big.Int).Div(maxGasTip, big.NewInt(10)))
-	boostedTip = boostedTip.Add(boostedTip, big.NewInt(1))
-
-	boostedBaseFee := new(big.Int).Add(maxBaseFee, new(big.Int).Div(maxBaseFee, big.NewInt(10)))
-	boostedBaseFee = boostedBaseFee.Add(boostedBaseFee, big.NewInt(1))
-
-	opts.GasTipCap = boostedTip
-	opts.GasFeeCap = new(big.Int).Add(boostedBaseFee, boostedTip)
-
-	c.logger.Debug("tip and base fee will be boosted by 10%")
 	c.logger.Debug(
 		"boosted gas",
-		"get_tip_cap", opts.GasTipCap.String(),
+		"strategy", c.gas.Strategy,
+		"bump_percent", c.gas.BumpPercent,
+		"gas_tip", opts.GasTipCap.String(),
 		"gas_fee_cap", opts.GasFeeCap.String(),
-		"base_fee", boostedBaseFee.String(),
+		"base_fee", new(big.Int).Sub(opts.GasFeeCap, opts.GasTipCap).String(),
 	)
-
 	return nil
 }
 
+// bumpByPercent returns v increased by percent, plus one wei so that the result
+// is strictly greater than v even for tiny values.
+func bumpByPercent(v *big.Int, percent uint64) *big.Int {
+	bump := new(big.Int).Mul(v, new(big.Int).SetUint64(percent))
+	bump.Div(bump, big.NewInt(100))
+	return bump.Add(bump, v).Add(bump, big.NewInt(1))
+}
+
 type TxSubmitFunc func(
 	ctx context.Context,
 	opts *bind.TransactOpts,
@@ -144,165 +232,401 @@ type TxSubmitFunc func(
 )
 
 // TODO: Unit tests
+// WaitMinedWithRetry submits the tx and waits for it to be included, replacing it with
+// boosted fees every bump interval. Every tx sent is tracked, so that an earlier attempt
+// being included counts as success. If the included tx failed, a *RevertError holding the
+// decoded revert reason is returned along with the MinedTx. The nonce of opts, which must have been obtained from
+// CreateTransactOpts, is released once it returns.
 func (c *ETHClient) WaitMinedWithRetry(
 	ctx context.Context,
 	opts *bind.TransactOpts,
 	submitTx TxSubmitFunc,
-) (*types.Receipt, error) {
+) (*MinedTx, error) {
+	return c.waitMinedWithRetry(ctx, opts, submitTx, nil)
+}
+
+// AdoptTransactOpts returns the opts of tx, a pending tx of the account sent by a previous
+// run, and marks its nonce as in flight until it is released by WaitAdoptedWithRetry.
+func (c *ETHClient) AdoptTransactOpts(
+	ctx context.Context,
+	signer Signer,
+	srcChainID *big.Int,
+	tx *types.Transaction,
+) (*bind.TransactOpts, error) {
+	auth, err := NewTransactOpts(ctx, signer, srcChainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transactor: %w", err)
+	}
+	c.nonceManager(auth.From).Adopt(tx.Nonce())
+	auth.Nonce = new(big.Int).SetUint64(tx.Nonce())
+	auth.GasTipCap = tx.GasTipCap()
+	auth.GasFeeCap = tx.GasFeeCap()
+	auth.GasLimit = tx.Gas()
+	return auth, nil
+}
+
+// WaitAdoptedWithRetry is WaitMinedWithRetry for tx, which is already pending, with opts
+// obtained from AdoptTransactOpts. tx is tracked as the first attempt and only replaced by
+// a tx built by submitTx with boosted fees once it was not included within the bump interval.
+func (c *ETHClient) WaitAdoptedWithRetry(
+	ctx context.Context,
+	opts *bind.TransactOpts,
+	tx *types.Transaction,
+	submitTx TxSubmitFunc,
+) (*MinedTx, error) {
+	return c.waitMinedWithRetry(ctx, opts, submitTx, tx)
+}
+
+// waitMinedWithRetry implements WaitMinedWithRetry. If adopted is not nil, it is tracked as
+// the tx of the first attempt instead of submitting one.
+func (c *ETHClient) waitMinedWithRetry(
+	ctx context.Context,
+	opts *bind.TransactOpts,
+	submitTx TxSubmitFunc,
+	adopted *types.Transaction,
+) (*MinedTx, error) {
+
+	maxRetries := c.gas.MaxAttempts
+	tracker := NewTxTracker(c.logger, c.client)
+	sentAt := time.Now()
 
-	const maxRetries = 10
-	var err error
-	var tx *types.Transaction
+	nonces := c.nonceManager(opts.From)
+	used := false // Whether any tx with the nonce reached the node
+	defer func() { nonces.Release(opts.Nonce.Uint64(), used) }()
 
+	skipBoost := false // Whether the last attempt is to be retried with the same fees
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			c.logger.Info("transaction not included within 60 seconds, boosting gas tip by 10%", "attempt", attempt)
-			if err := c.BoostTipForTransactOpts(ctx, opts); err != nil {
+		skipSubmit := false // Whether to only wait on the txs tracked so far
+		if attempt == 0 && adopted != nil {
+			tracker.Add(attempt, adopted)
+			skipSubmit = true
+		}
+		if attempt > 0 && !skipBoost {
+			c.logger.Info(
+				"transaction not included in time, boosting gas tip",
+				"attempt", attempt,
+				"bump_interval", c.gas.BumpInterval,
+				"bump_percent", c.gas.BumpPercent,
+			)
+			c.metrics.FeeBump()
+			err := c.BoostTipForTransactOpts(ctx, opts)
+			switch {
+			case errors.Is(err, ErrMaxFeeCapReached) && len(tracker.Attempts()) > 0:
+				// Keep waiting on the txs sent so far rather than failing the request.
+				c.logger.Warn("max fee cap reached, waiting on txs sent so far", "attempt", attempt, "error", err)
+				skipSubmit = true
+			case err != nil:
 				return nil, fmt.Errorf("failed to boost gas tip for attempt %d: %w", attempt, err)
 			}
 		}
 
-		tx, err = submitTx(ctx, opts)
-		if err != nil {
-			if strings.Contains(err.Error(), "replacement transaction underpriced") || strings.Contains(err.Error(), "already known") {
-				c.logger.Error("tx submission failed", "attempt", attempt, "error", err)
+		skipBoost = false
+		if !skipSubmit {
+			tx, err := submitTx(ctx, opts)
+			err = ClassifyError(err)
+			switch {
+			case err == nil:
+				tracker.Add(attempt, tx)
+			case errors.Is(err, ErrUnderpriced), errors.Is(err, ErrAlreadyKnown):
+				// A tx with the nonce is pending, either an earlier attempt or the same tx.
+				c.logger.Warn("tx submission failed", "attempt", attempt, "error", err)
+				used = true
+				if len(tracker.Attempts()) == 0 {
+					continue
+				}
+				// Earlier attempts are still pending, wait on them.
+			case errors.Is(err, ErrFeeCapTooLow):
+				// The base fee rose above the fee cap, bump right away.
+				c.logger.Warn("tx fee cap too low, boosting right away", "attempt", attempt, "error", err)
+				continue
+			case errors.Is(err, ErrIntrinsicGas):
+				if opts.GasLimit >= c.gas.Limit {
+					return nil, fmt.Errorf("tx submission failed on attempt %d: %w", attempt, err)
+				}
+				c.logger.Warn("tx gas limit too low, raising it to the ceiling", "gas_limit", opts.GasLimit, "ceiling", c.gas.Limit)
+				opts.GasLimit = c.gas.Limit
+				skipBoost = true
 				continue
+			case errors.Is(err, ErrRateLimited), errors.Is(err, ErrTxPoolFull):
+				c.logger.Warn("tx submission throttled, backing off", "attempt", attempt, "backoff", submitBackoff, "error", err)
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(submitBackoff):
+				}
+				skipBoost = true
+				continue
+			case errors.Is(err, ErrNonceTooLow):
+				used = true
+				// An earlier attempt may have been included in the meantime.
+				if mined, checkErr := tracker.Check(ctx); checkErr == nil && mined != nil {
+					return c.minedResult(ctx, opts.From, mined, sentAt)
+				}
+				// The nonce was used by another tx, resync before handing out more.
+				nonces.Resync()
+				return nil, fmt.Errorf("tx submission failed on attempt %d: %w", attempt, err)
+			case errors.Is(err, ErrNonceTooHigh):
+				nonces.Resync()
+				return nil, fmt.Errorf("tx submission failed on attempt %d: %w", attempt, err)
+			default:
+				// Including insufficient funds, which only the operator can recover from.
+				return nil, fmt.Errorf("tx submission failed on attempt %d: %w", attempt, err)
 			}
-			return nil, fmt.Errorf("tx submission failed on attempt %d: %w", attempt, err)
 		}
+		used = true
 
-		timeoutCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
-		receiptChan := make(chan *types.Receipt)
-		errChan := make(chan error)
-
-		go func() {
-			receipt, err := bind.WaitMined(timeoutCtx, c.client, tx)
-			if err != nil {
-				errChan <- err
-				return
-			}
-			receiptChan <- receipt
-		}()
-
-		select {
-		case receipt := <-receiptChan:
-			cancel()
-			return receipt, nil
-		case err := <-errChan:
-			cancel()
-			return nil, err
-		case <-timeoutCtx.Done():
-			cancel()
-			if attempt == maxRetries-1 {
-				return nil, fmt.Errorf("tx not included after %d attempts", maxRetries)
-			}
-			// Continue with boosted tip
+		timeoutCtx, cancel := context.WithTimeout(ctx, c.gas.BumpInterval)
+		mined, err := tracker.Wait(timeoutCtx)
+		cancel()
+		if err == nil {
+			return c.minedResult(ctx, opts.From, mined, sentAt)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
+		if attempt == maxRetries-1 {
+			return nil, fmt.Errorf("tx not included after %d attempts", maxRetries)
+		}
+		// Continue with boosted tip
 	}
 	return nil, fmt.Errorf("unexpected error: control flow should not reach end of WaitMinedWithRetry")
 }
 
-func (c *ETHClient) CancelPendingTxes(ctx context.Context, privateKey *ecdsa.PrivateKey) error {
-	if err := c.cancelAllPendingTransactions(ctx, privateKey); err != nil {
-		return err
+// minedResult logs and records the included tx, whose first attempt was sent at sentAt,
+// and returns a RevertError along with mined if it failed.
+func (c *ETHClient) minedResult(ctx context.Context, from common.Address, mined *MinedTx, sentAt time.Time) (*MinedTx, error) {
+	receipt := mined.Receipt
+	c.metrics.TxIncluded(receipt.Status == types.ReceiptStatusSuccessful, receipt.GasUsed, receipt.EffectiveGasPrice, sentAt)
+	if mined.Receipt.Status == types.ReceiptStatusFailed {
+		revertErr := c.revertError(ctx, from, mined.Attempt.tx, mined.Receipt)
+		c.logger.Warn(
+			"tx reverted",
+			"tx_hash", mined.Receipt.TxHash.Hex(),
+			"attempt", mined.Attempt.Attempt,
+			"block_number", mined.Receipt.BlockNumber,
+			"reason", revertErr.Reason,
+		)
+		return mined, revertErr
 	}
+	c.logger.Info(
+		"tx included",
+		"tx_hash", mined.Receipt.TxHash.Hex(),
+		"attempt", mined.Attempt.Attempt,
+		"attempts", len(mined.Attempts),
+		"gas_tip", mined.Attempt.GasTipCap.String(),
+		"gas_fee_cap", mined.Attempt.GasFeeCap.String(),
+		"block_number", mined.Receipt.BlockNumber,
+	)
+	return mined, nil
+}
 
-	idx := 0
-	timeoutSec := 60
-	for {
-		if idx >= timeoutSec {
-			return fmt.Errorf("timeout: failed to cancel all pending transactions")
-		}
-		exist, err := c.PendingTransactionsExist(ctx, privateKey)
-		if err != nil {
-			return fmt.Errorf("failed to check pending transactions: %w", err)
-		}
-		if !exist {
-			c.logger.Info("all pending transactions for signing account have been cancelled")
-			return nil
-		}
-		time.Sleep(1 * time.Second)
-		idx++
+// ReplaceStatus is the outcome of replacing the pending tx of a nonce.
+type ReplaceStatus string
+
+const (
+	// TxReplaced means a replacement tx was included in place of the pending tx.
+	TxReplaced ReplaceStatus = "replaced"
+	// TxAlreadyIncluded means the pending tx was included before it could be replaced.
+	TxAlreadyIncluded ReplaceStatus = "already_included"
+	// TxReplaceFailed means the nonce is still pending.
+	TxReplaceFailed ReplaceStatus = "failed"
+)
+
+// ReplaceReport is the outcome of cancelling or speeding up the pending tx of a nonce.
+type ReplaceReport struct {
+	Nonce  uint64
+	Status ReplaceStatus
+	// Mined is the included replacement tx, only set if Status is TxReplaced.
+	Mined *MinedTx
+	Err   error
+}
+
+func (r ReplaceReport) String() string {
+	switch r.Status {
+	case TxReplaced:
+		return fmt.Sprintf("nonce %d: replaced by %s on attempt %d",
+			r.Nonce, r.Mined.Receipt.TxHash.Hex(), r.Mined.Attempt.Attempt)
+	case TxReplaceFailed:
+		return fmt.Sprintf("nonce %d: failed: %v", r.Nonce, r.Err)
+	}
+	if r.Err != nil {
+		return fmt.Sprintf("nonce %d: %s: %v", r.Nonce, r.Status, r.Err)
+	}
+	return fmt.Sprintf("nonce %d: %s", r.Nonce, r.Status)
+}
+
+// CancelPendingTxes replaces every pending tx of the account with a 0-value self-send and
+// waits for each of them to be included, lowest nonce first. It stops at the first nonce
+// that cannot be cancelled and returns a report for every nonce it attempted.
+func (c *ETHClient) CancelPendingTxes(ctx context.Context, signer Signer) ([]ReplaceReport, error) {
+	reports, err := c.cancelAllPendingTransactions(ctx, signer)
+	if err != nil {
+		return reports, err
+	}
+	exist, err := c.PendingTransactionsExist(ctx, signer.Address())
+	if err != nil {
+		return reports, fmt.Errorf("failed to check pending transactions: %w", err)
+	}
+	if exist {
+		// Txs sent by someone else with the same key meanwhile.
+		return reports, errors.New("pending transactions remain after cancellation")
 	}
+	c.logger.Info("all pending transactions for signing account have been cancelled", "nonces", len(reports))
+	return reports, nil
 }
 
-// TODO: Use WaitMinedWithRetry
 func (c *ETHClient) cancelAllPendingTransactions(
 	ctx context.Context,
-	privateKey *ecdsa.PrivateKey,
-) error {
+	signer Signer,
+) ([]ReplaceReport, error) {
 	chainID, err := c.ChainID(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get chain id: %w", err)
+		return nil, fmt.Errorf("failed to get chain id: %w", err)
 	}
-	fromAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
-	currentNonce, err := c.client.PendingNonceAt(ctx, fromAddress)
+	pending, err := c.FindPendingTxes(ctx, signer.Address(), nil)
 	if err != nil {
-		return fmt.Errorf("failed to get current pending nonce: %w", err)
+		return nil, err
+	}
+	if pending.To <= pending.From {
+		c.logger.Info("no pending transactions to cancel")
+		return nil, nil
+	}
+
+	var reports []ReplaceReport
+	for nonce := pending.From; nonce < pending.To; nonce++ {
+		report := c.cancelNonce(ctx, signer, chainID, nonce, pending.Txs[nonce])
+		reports = append(reports, report)
+		if report.Status == TxReplaceFailed {
+			return reports, fmt.Errorf("failed to cancel transaction with nonce %d: %w", nonce, report.Err)
+		}
+	}
+	return reports, nil
+}
+
+// pendingNonceRange returns the nonce of the next tx to be included and the next nonce
+// after the txs pending in the pool. Nonces in between belong to pending txs.
+func (c *ETHClient) pendingNonceRange(ctx context.Context, account common.Address) (uint64, uint64, error) {
+	currentNonce, err := c.client.PendingNonceAt(ctx, account)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get current pending nonce: %w", err)
 	}
 	c.logger.Debug("current pending nonce", "nonce", currentNonce)
 
-	latestNonce, err := c.client.NonceAt(ctx, fromAddress, nil)
+	latestNonce, err := c.client.NonceAt(ctx, account, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get latest nonce: %w", err)
+		return 0, 0, fmt.Errorf("failed to get latest nonce: %w", err)
 	}
 	c.logger.Debug("latest nonce", "nonce", latestNonce)
+	return latestNonce, currentNonce, nil
+}
 
-	if currentNonce <= latestNonce {
-		c.logger.Info("no pending transactions to cancel")
-		return nil
+// CancelNonce replaces pending, the pending tx of nonce, with a 0-value self-send and waits
+// for it to be included. pending may be nil if the pending tx could not be found.
+func (c *ETHClient) CancelNonce(
+	ctx context.Context,
+	signer Signer,
+	nonce uint64,
+	pending *types.Transaction,
+) ReplaceReport {
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return ReplaceReport{Nonce: nonce, Status: TxReplaceFailed, Err: fmt.Errorf("failed to get chain id: %w", err)}
 	}
+	return c.cancelNonce(ctx, signer, chainID, nonce, pending)
+}
 
-	suggestedGasPrice, err := c.client.SuggestGasPrice(ctx)
+// cancelNonce replaces the pending tx of nonce with a 0-value self-send. Its fees exceed
+// those of pending, if known, by the minimum replacement bump, so that nodes accept it
+// right away. Otherwise they are bumped through WaitMinedWithRetry until it is accepted.
+func (c *ETHClient) cancelNonce(
+	ctx context.Context,
+	signer Signer,
+	chainID *big.Int,
+	nonce uint64,
+	pending *types.Transaction,
+) ReplaceReport {
+	suggested, err := c.strategy.SuggestFees(ctx, time.Time{})
 	if err != nil {
-		return fmt.Errorf("failed to get suggested gas price: %w", err)
+		return ReplaceReport{Nonce: nonce, Status: TxReplaceFailed, Err: fmt.Errorf("failed to suggest fees: %w", err)}
 	}
-	c.logger.Debug("suggested gas price", "gas_price", suggestedGasPrice.String())
-
-	for nonce := latestNonce; nonce < currentNonce; nonce++ {
-		gasPrice := new(big.Int).Set(suggestedGasPrice)
-		const maxRetries = 5
-		for retry := 0; retry < maxRetries; retry++ {
-			if retry > 0 {
-				increase := new(big.Int).Div(gasPrice, big.NewInt(10))
-				gasPrice = gasPrice.Add(gasPrice, increase)
-				gasPrice = gasPrice.Add(gasPrice, big.NewInt(1))
-				c.logger.Debug("increased gas price for retry", "retry", retry, "gas_price", gasPrice.String())
-			}
-
-			tx := types.NewTransaction(nonce, fromAddress, big.NewInt(0), 21000, gasPrice, nil)
-			signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
-			if err != nil {
-				return fmt.Errorf("failed to sign cancellation transaction for nonce %d: %w", nonce, err)
-			}
+	fees := replacementFees(suggested, pending)
+	from := signer.Address()
+	return c.replaceNonce(ctx, signer, chainID, &types.DynamicFeeTx{
+		Nonce:     nonce,
+		GasTipCap: fees.TipCap,
+		GasFeeCap: fees.FeeCap,
+		Gas:       params.TxGas,
+		To:        &from,
+		Value:     big.NewInt(0),
+	})
+}
 
-			err = c.client.SendTransaction(ctx, signedTx)
-			if err != nil {
-				if err.Error() == "replacement transaction underpriced" {
-					c.logger.Warn("underpriced transaction, increasing gas price", "retry", retry+1, "nonce", nonce, "error", err)
-					continue // Try again with a higher gas price
-				}
-				if err.Error() == "already known" {
-					c.logger.Warn("already known transaction", "retry", retry+1, "nonce", nonce, "error", err)
-					continue // Try again with a higher gas price
-				}
-				return fmt.Errorf("failed to send cancellation transaction for nonce %d: %w", nonce, err)
-			}
-			c.logger.Info("sent cancel transaction", "nonce", nonce, "tx_hash", signedTx.Hash().Hex(), "gas_price", gasPrice.String())
-			break
+// replaceNonce sends replacement as a dynamic fee tx with the nonce, fees and gas limit it
+// holds, and bumps its fees through WaitMinedWithRetry until it replaces the pending tx.
+func (c *ETHClient) replaceNonce(
+	ctx context.Context,
+	signer Signer,
+	chainID *big.Int,
+	replacement *types.DynamicFeeTx,
+) ReplaceReport {
+	nonce := replacement.Nonce
+	report := ReplaceReport{Nonce: nonce, Status: TxReplaceFailed}
+	opts, err := NewTransactOpts(ctx, signer, chainID)
+	if err != nil {
+		report.Err = fmt.Errorf("failed to create transactor: %w", err)
+		return report
+	}
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	opts.GasTipCap = replacement.GasTipCap
+	opts.GasFeeCap = replacement.GasFeeCap
+	opts.GasLimit = replacement.Gas
+
+	submitReplacement := func(ctx context.Context, opts *bind.TransactOpts) (*types.Transaction, error) {
+		txData := *replacement
+		txData.ChainID = chainID
+		txData.GasTipCap = opts.GasTipCap
+		txData.GasFeeCap = opts.GasFeeCap
+		txData.Gas = opts.GasLimit
+		tx, err := opts.Signer(opts.From, types.NewTx(&txData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign replacement tx: %w", err)
+		}
+		if err := c.client.SendTransaction(ctx, tx); err != nil {
+			return nil, err
 		}
+		c.logger.Info(
+			"sent replacement transaction",
+			"nonce", nonce,
+			"tx_hash", tx.Hash().Hex(),
+			"gas_tip", opts.GasTipCap.String(),
+			"gas_fee_cap", opts.GasFeeCap.String(),
+		)
+		return tx, nil
 	}
-	return nil
+
+	mined, err := c.WaitMinedWithRetry(ctx, opts, submitReplacement)
+	switch {
+	case err == nil:
+		report.Status, report.Mined = TxReplaced, mined
+	case errors.Is(err, ErrTxReverted):
+		// The nonce is no longer pending, but the replacement did not do what it should.
+		report.Status, report.Mined, report.Err = TxReplaced, mined, err
+	case errors.Is(err, ErrNonceTooLow):
+		report.Status = TxAlreadyIncluded
+		c.logger.Info("pending transaction included before it could be replaced", "nonce", nonce)
+	default:
+		report.Err = err
+	}
+	return report
 }
 
-func (c *ETHClient) PendingTransactionsExist(ctx context.Context, privateKey *ecdsa.PrivateKey) (bool, error) {
-	fromAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
-	currentNonce, err := c.client.PendingNonceAt(ctx, fromAddress)
+func (c *ETHClient) PendingTransactionsExist(ctx context.Context, account common.Address) (bool, error) {
+	currentNonce, err := c.client.PendingNonceAt(ctx, account)
 	if err != nil {
 		return false, fmt.Errorf("failed to get current pending nonce: %w", err)
 	}
 
-	latestNonce, err := c.client.NonceAt(ctx, fromAddress, nil)
+	latestNonce, err := c.client.NonceAt(ctx, account, nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to get latest nonce: %w", err)
 	}
@@ -0,0 +1,126 @@
+package shared
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Classes of errors returned by nodes when sending txs or making calls. ClassifyError
+// wraps an error so that errors.Is matches its class.
+var (
+	// ErrUnderpriced means a pending tx with the same nonce has fees too close to the new one.
+	ErrUnderpriced = errors.New("replacement tx underpriced")
+	// ErrAlreadyKnown means the exact same tx is already in the pool.
+	ErrAlreadyKnown = errors.New("tx already known")
+	// ErrNonceTooLow means the nonce was used by an included tx.
+	ErrNonceTooLow = errors.New("nonce too low")
+	// ErrNonceTooHigh means the nonce leaves a gap the node does not accept.
+	ErrNonceTooHigh = errors.New("nonce too high")
+	// ErrInsufficientFunds means the account cannot pay for value plus gas limit times fee cap.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrIntrinsicGas means the gas limit is below the intrinsic gas of the tx.
+	ErrIntrinsicGas = errors.New("intrinsic gas too low")
+	// ErrFeeCapTooLow means the fee cap is below the base fee or the minimum accepted by the pool.
+	ErrFeeCapTooLow = errors.New("fee cap too low")
+	// ErrGasLimitTooHigh means the gas limit exceeds the block gas limit.
+	ErrGasLimitTooHigh = errors.New("gas limit exceeds block gas limit")
+	// ErrTxPoolFull means the pool does not accept any more txs.
+	ErrTxPoolFull = errors.New("tx pool full")
+	// ErrRateLimited means the endpoint throttles the caller.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrExecutionReverted means a call or gas estimation reverted.
+	ErrExecutionReverted = errors.New("execution reverted")
+)
+
+// errorPatterns maps substrings of the normalized messages of geth, erigon, nethermind,
+// besu and common hosted rpcs to the class they indicate. Order matters, more specific first.
+var errorPatterns = []struct {
+	pattern string
+	class   error
+}{
+	{"replacement transaction underpriced", ErrUnderpriced},
+	{"replacement underpriced", ErrUnderpriced},
+	{"could not replace existing tx", ErrUnderpriced},
+	{"replacementnotallowed", ErrUnderpriced},
+	{"already known", ErrAlreadyKnown},
+	{"known transaction", ErrAlreadyKnown},
+	{"alreadyknown", ErrAlreadyKnown},
+	{"already imported", ErrAlreadyKnown},
+	{"nonce too low", ErrNonceTooLow},
+	{"oldnonce", ErrNonceTooLow},
+	{"nonce too high", ErrNonceTooHigh},
+	{"nonce gap", ErrNonceTooHigh},
+	{"insufficient funds", ErrInsufficientFunds},
+	{"upfront cost exceeds balance", ErrInsufficientFunds},
+	{"insufficientfunds", ErrInsufficientFunds},
+	{"intrinsic gas too low", ErrIntrinsicGas},
+	{"intrinsic gas exceeds gas limit", ErrIntrinsicGas},
+	{"intrinsicgas", ErrIntrinsicGas},
+	{"max fee per gas less than block base fee", ErrFeeCapTooLow},
+	{"fee cap less than block base fee", ErrFeeCapTooLow},
+	{"gas price below minimum", ErrFeeCapTooLow},
+	{"transaction underpriced", ErrFeeCapTooLow},
+	{"feetoolow", ErrFeeCapTooLow},
+	{"exceeds block gas limit", ErrGasLimitTooHigh},
+	{"txpool is full", ErrTxPoolFull},
+	{"transaction pool is full", ErrTxPoolFull},
+	{"rate limit", ErrRateLimited},
+	{"too many requests", ErrRateLimited},
+	{"request limit", ErrRateLimited},
+	{"execution reverted", ErrExecutionReverted},
+}
+
+// classifiedError is an error along with the class it belongs to.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.class
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// ClassifyError returns err wrapped so that errors.Is matches its class, or err unchanged
+// if it is nil, already classified or of no known class.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *classifiedError
+	if errors.As(err, &classified) {
+		return err
+	}
+	if class := errorClass(err); class != nil {
+		return &classifiedError{class: class, err: err}
+	}
+	return err
+}
+
+func errorClass(err error) error {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcLimitExceededCode {
+		return ErrRateLimited
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	msg := strings.ToLower(strings.ReplaceAll(err.Error(), "_", " "))
+	for _, p := range errorPatterns {
+		if strings.Contains(msg, p.pattern) {
+			return p.class
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcError is an error returned by a node with a JSON-RPC error code.
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error // Nil if the error has no class.
+	}{
+		{name: "geth underpriced", err: errors.New("replacement transaction underpriced"), want: ErrUnderpriced},
+		{name: "nethermind underpriced", err: errors.New("ReplacementNotAllowed"), want: ErrUnderpriced},
+		{name: "erigon underpriced", err: errors.New("could not replace existing tx"), want: ErrUnderpriced},
+		{name: "geth already known", err: errors.New("already known"), want: ErrAlreadyKnown},
+		{name: "besu already known", err: errors.New("Known transaction"), want: ErrAlreadyKnown},
+		{name: "nethermind already known", err: errors.New("AlreadyKnown"), want: ErrAlreadyKnown},
+		{name: "nonce too low", err: errors.New("nonce too low: next nonce 5, tx nonce 4"), want: ErrNonceTooLow},
+		{name: "besu nonce too low", err: errors.New("NONCE_TOO_LOW"), want: ErrNonceTooLow},
+		{name: "nethermind nonce too low", err: errors.New("OldNonce"), want: ErrNonceTooLow},
+		{name: "nonce too high", err: errors.New("nonce too high"), want: ErrNonceTooHigh},
+		{
+			name: "insufficient funds",
+			err:  errors.New("insufficient funds for gas * price + value: balance 0"),
+			want: ErrInsufficientFunds,
+		},
+		{name: "besu insufficient funds", err: errors.New("UPFRONT_COST_EXCEEDS_BALANCE"), want: ErrInsufficientFunds},
+		{name: "intrinsic gas", err: errors.New("intrinsic gas too low"), want: ErrIntrinsicGas},
+		{
+			name: "fee cap below base fee",
+			err:  errors.New("max fee per gas less than block base fee"),
+			want: ErrFeeCapTooLow,
+		},
+		// "transaction underpriced" is a substring of "replacement transaction underpriced",
+		// which must still be classified as a replacement error.
+		{name: "pool minimum", err: errors.New("transaction underpriced"), want: ErrFeeCapTooLow},
+		{name: "block gas limit", err: errors.New("exceeds block gas limit"), want: ErrGasLimitTooHigh},
+		{name: "pool full", err: errors.New("txpool is full"), want: ErrTxPoolFull},
+		{name: "rate limit message", err: errors.New("Too Many Requests"), want: ErrRateLimited},
+		{name: "rate limit code", err: rpcError{code: rpcLimitExceededCode, msg: "slow down"}, want: ErrRateLimited},
+		{
+			name: "rate limit http status",
+			err:  rpc.HTTPError{StatusCode: http.StatusTooManyRequests, Status: "429"},
+			want: ErrRateLimited,
+		},
+		{name: "revert", err: errors.New("execution reverted: not authorized"), want: ErrExecutionReverted},
+		{name: "wrapped", err: fmt.Errorf("failed to send tx: %w", errors.New("nonce too low")), want: ErrNonceTooLow},
+		{name: "unknown", err: errors.New("connection refused")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyError(tt.err)
+			if got.Error() != tt.err.Error() {
+				t.Fatalf("ClassifyError() changed the message to %q", got.Error())
+			}
+			if tt.want == nil {
+				if got != tt.err {
+					t.Fatalf("ClassifyError() = %v, want the error unchanged", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Fatalf("ClassifyError(%q) is not %v", tt.err, tt.want)
+			}
+			if ClassifyError(got) != got {
+				t.Fatal("ClassifyError() classified an error twice")
+			}
+		})
+	}
+	if ClassifyError(nil) != nil {
+		t.Fatal("ClassifyError(nil) != nil")
+	}
+}
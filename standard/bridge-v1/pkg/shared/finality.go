@@ -0,0 +1,89 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type FinalityMode string
+
+const (
+	// FinalityFinalized trusts the node's "finalized" block tag.
+	FinalityFinalized FinalityMode = "finalized"
+	// FinalitySafe trusts the node's "safe" block tag.
+	FinalitySafe FinalityMode = "safe"
+	// FinalityConfirmations considers blocks final once they are buried under N blocks.
+	FinalityConfirmations FinalityMode = "confirmations"
+	// FinalityInstant considers the latest block final, e.g. for PoA chains.
+	FinalityInstant FinalityMode = "instant"
+)
+
+// FinalityPolicy decides which blocks of a chain are considered final.
+type FinalityPolicy struct {
+	Mode          FinalityMode
+	Confirmations uint64 // Only used with FinalityConfirmations.
+}
+
+// ParseFinalityPolicy parses one of "finalized", "safe", "instant" or "confirmations:<N>".
+func ParseFinalityPolicy(s string) (FinalityPolicy, error) {
+	mode, arg, hasArg := strings.Cut(s, ":")
+	switch FinalityMode(mode) {
+	case FinalityFinalized, FinalitySafe, FinalityInstant:
+		if hasArg {
+			return FinalityPolicy{}, fmt.Errorf("finality mode %q takes no argument", mode)
+		}
+		return FinalityPolicy{Mode: FinalityMode(mode)}, nil
+	case FinalityConfirmations:
+		n, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return FinalityPolicy{}, fmt.Errorf("invalid confirmations %q: %w", arg, err)
+		}
+		return FinalityPolicy{Mode: FinalityConfirmations, Confirmations: n}, nil
+	default:
+		return FinalityPolicy{}, fmt.Errorf("unknown finality mode: %q", mode)
+	}
+}
+
+func (p FinalityPolicy) String() string {
+	if p.Mode == FinalityConfirmations {
+		return string(p.Mode) + ":" + strconv.FormatUint(p.Confirmations, 10)
+	}
+	return string(p.Mode)
+}
+
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// FinalizedBlockNum returns the most recent block considered final under the policy.
+func (p FinalityPolicy) FinalizedBlockNum(ctx context.Context, client HeaderReader) (uint64, error) {
+	var tag *big.Int
+	switch p.Mode {
+	case FinalityFinalized:
+		tag = big.NewInt(int64(rpc.FinalizedBlockNumber))
+	case FinalitySafe:
+		tag = big.NewInt(int64(rpc.SafeBlockNumber))
+	case FinalityConfirmations, FinalityInstant:
+		tag = nil // Latest
+	default:
+		return 0, fmt.Errorf("unknown finality mode: %q", p.Mode)
+	}
+	header, err := client.HeaderByNumber(ctx, tag)
+	if err != nil {
+		return 0, fmt.Errorf("failed to obtain %s block header: %w", p, err)
+	}
+	blockNum := header.Number.Uint64()
+	if p.Mode != FinalityConfirmations {
+		return blockNum, nil
+	}
+	if blockNum < p.Confirmations {
+		return 0, nil
+	}
+	return blockNum - p.Confirmations, nil
+}
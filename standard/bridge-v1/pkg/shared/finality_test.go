@@ -0,0 +1,90 @@
+package shared
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestParseFinalityPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FinalityPolicy
+		wantErr bool
+	}{
+		{in: "finalized", want: FinalityPolicy{Mode: FinalityFinalized}},
+		{in: "safe", want: FinalityPolicy{Mode: FinalitySafe}},
+		{in: "instant", want: FinalityPolicy{Mode: FinalityInstant}},
+		{in: "confirmations:64", want: FinalityPolicy{Mode: FinalityConfirmations, Confirmations: 64}},
+		{in: "confirmations:0", want: FinalityPolicy{Mode: FinalityConfirmations}},
+		{in: "confirmations", wantErr: true},
+		{in: "confirmations:-1", wantErr: true},
+		{in: "confirmations:abc", wantErr: true},
+		{in: "finalized:1", wantErr: true},
+		{in: "latest", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseFinalityPolicy(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseFinalityPolicy(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFinalityPolicy(%q) failed: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseFinalityPolicy(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+			if got.String() != tt.in {
+				t.Fatalf("String() = %q, want %q", got.String(), tt.in)
+			}
+		})
+	}
+}
+
+// headers serves a header numbered after the tag it is requested with.
+type headers map[int64]uint64
+
+func (h headers) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	tag := int64(rpc.LatestBlockNumber)
+	if number != nil {
+		tag = number.Int64()
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(h[tag])}, nil
+}
+
+func TestFinalizedBlockNum(t *testing.T) {
+	client := headers{
+		int64(rpc.LatestBlockNumber):    100,
+		int64(rpc.SafeBlockNumber):      90,
+		int64(rpc.FinalizedBlockNumber): 80,
+	}
+	tests := []struct {
+		policy FinalityPolicy
+		want   uint64
+	}{
+		{policy: FinalityPolicy{Mode: FinalityFinalized}, want: 80},
+		{policy: FinalityPolicy{Mode: FinalitySafe}, want: 90},
+		{policy: FinalityPolicy{Mode: FinalityInstant}, want: 100},
+		{policy: FinalityPolicy{Mode: FinalityConfirmations, Confirmations: 12}, want: 88},
+		{policy: FinalityPolicy{Mode: FinalityConfirmations, Confirmations: 200}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			got, err := tt.policy.FinalizedBlockNum(context.Background(), client)
+			if err != nil {
+				t.Fatalf("FinalizedBlockNum() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("FinalizedBlockNum() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
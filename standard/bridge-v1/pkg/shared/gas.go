@@ -0,0 +1,293 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// GasStrategyNode uses the tip cap and gas price suggested by the node.
+	GasStrategyNode = "node"
+	// GasStrategyFeeHistory derives the tip cap from a percentile of the tips paid
+	// in recent blocks and the fee cap from the base fee of the next block.
+	GasStrategyFeeHistory = "fee_history"
+	// GasStrategyFixed always uses the configured tip cap and fee cap.
+	GasStrategyFixed = "fixed"
+
+	defaultFeeHistoryBlocks     = 20
+	defaultFeeHistoryPercentile = 50
+	defaultAggressiveTipPercent = 100
+
+	// minReplacementBumpPercent is the minimum fee bump geth, erigon, nethermind and besu
+	// require by default to replace a pending tx, on both the tip and the fee cap.
+	minReplacementBumpPercent = 10
+)
+
+// GasStrategies lists the names of all gas strategies.
+var GasStrategies = []string{GasStrategyNode, GasStrategyFeeHistory, GasStrategyFixed}
+
+// ErrMaxFeeCapReached is returned when fees cannot be bumped any further without
+// exceeding the max fee cap of the chain.
+var ErrMaxFeeCapReached = errors.New("max fee cap reached")
+
+// FeeHistoryConfig configures the fee_history gas strategy.
+type FeeHistoryConfig struct {
+	// Blocks is the number of recent blocks sampled.
+	Blocks uint64 `yaml:"blocks"`
+	// Percentile of the tips paid in each block, in [0, 100].
+	Percentile float64 `yaml:"percentile"`
+}
+
+// FixedFeesConfig configures the fixed gas strategy.
+type FixedFeesConfig struct {
+	TipCapGwei float64 `yaml:"tip_cap_gwei"`
+	FeeCapGwei float64 `yaml:"fee_cap_gwei"`
+}
+
+// AggressiveConfig raises the tip of txs acting on old requests, e.g. finalizations of
+// transfers initiated long ago. It applies on top of any strategy and is disabled if After is 0.
+type AggressiveConfig struct {
+	// After is the age of a request from which its txs are sent with a raised tip.
+	After time.Duration `yaml:"after"`
+	// TipPercent is the percentage by which the tip is raised.
+	TipPercent uint64 `yaml:"tip_percent"`
+}
+
+// Fees are the EIP-1559 fees of a tx.
+type Fees struct {
+	TipCap *big.Int
+	FeeCap *big.Int
+}
+
+// GasStrategy decides the fees of txs and of their replacements.
+type GasStrategy interface {
+	// SuggestFees returns the fees of a new tx acting on a request made at requestedAt.
+	// A zero requestedAt means the request was just made.
+	SuggestFees(ctx context.Context, requestedAt time.Time) (Fees, error)
+	// BumpFees returns the fees of a replacement of a tx sent with prev. It returns
+	// ErrMaxFeeCapReached if the fees cannot be raised enough to replace the tx.
+	BumpFees(ctx context.Context, prev Fees) (Fees, error)
+}
+
+// FeeSuggester is implemented by clients able to suggest fees.
+type FeeSuggester interface {
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	FeeHistory(
+		ctx context.Context,
+		blockCount uint64,
+		lastBlock *big.Int,
+		rewardPercentiles []float64,
+	) (*ethereum.FeeHistory, error)
+}
+
+// NewGasStrategy returns the strategy selected by cfg, which must have been validated.
+func NewGasStrategy(cfg GasConfig, client FeeSuggester) GasStrategy {
+	limits := feeLimits{bumpPercent: cfg.BumpPercent}
+	if cfg.MaxFeeCapGwei > 0 {
+		limits.maxFeeCap = gweiToWei(cfg.MaxFeeCapGwei)
+	}
+	var s GasStrategy
+	switch cfg.Strategy {
+	case GasStrategyFeeHistory:
+		s = &feeHistoryStrategy{
+			feeLimits:  limits,
+			client:     client,
+			blocks:     cfg.FeeHistory.Blocks,
+			percentile: cfg.FeeHistory.Percentile,
+		}
+	case GasStrategyFixed:
+		s = &fixedStrategy{
+			feeLimits: limits,
+			fees: Fees{
+				TipCap: gweiToWei(cfg.Fixed.TipCapGwei),
+				FeeCap: gweiToWei(cfg.Fixed.FeeCapGwei),
+			},
+		}
+	default:
+		s = &nodeStrategy{feeLimits: limits, client: client}
+	}
+	if cfg.Aggressive.After > 0 {
+		s = &aggressiveStrategy{
+			GasStrategy: s,
+			feeLimits:   limits,
+			after:       cfg.Aggressive.After,
+			tipPercent:  cfg.Aggressive.TipPercent,
+		}
+	}
+	return s
+}
+
+// feeLimits implements the bumping and capping shared by all strategies.
+type feeLimits struct {
+	bumpPercent uint64
+	maxFeeCap   *big.Int // Nil if unlimited
+}
+
+// capFees lowers the fee cap to the max fee cap, and the tip cap to the fee cap.
+func (l feeLimits) capFees(f Fees) Fees {
+	if l.maxFeeCap != nil && f.FeeCap.Cmp(l.maxFeeCap) > 0 {
+		f.FeeCap = new(big.Int).Set(l.maxFeeCap)
+	}
+	if f.TipCap.Cmp(f.FeeCap) > 0 {
+		f.TipCap = new(big.Int).Set(f.FeeCap)
+	}
+	return f
+}
+
+// bump raises the tip and the base fee headroom of prev by the bump percentage, or
+// to the ones of fresh if those are higher.
+func (l feeLimits) bump(prev, fresh Fees) (Fees, error) {
+	prevBaseFee := new(big.Int).Sub(prev.FeeCap, prev.TipCap)
+	if prevBaseFee.Sign() < 0 {
+		return Fees{}, fmt.Errorf("base fee cannot be negative: %s", prevBaseFee)
+	}
+	freshBaseFee := new(big.Int).Sub(fresh.FeeCap, fresh.TipCap)
+	if freshBaseFee.Sign() < 0 {
+		return Fees{}, fmt.Errorf("new base fee cannot be negative: %s", freshBaseFee)
+	}
+	tip := bumpByPercent(bigMax(prev.TipCap, fresh.TipCap), l.bumpPercent)
+	baseFee := bumpByPercent(bigMax(prevBaseFee, freshBaseFee), l.bumpPercent)
+	bumped := l.capFees(Fees{TipCap: tip, FeeCap: new(big.Int).Add(baseFee, tip)})
+	if bumped.FeeCap.Cmp(prev.FeeCap) <= 0 {
+		return Fees{}, fmt.Errorf("fee cap %s: %w", prev.FeeCap, ErrMaxFeeCapReached)
+	}
+	return bumped, nil
+}
+
+type nodeStrategy struct {
+	feeLimits
+	client FeeSuggester
+}
+
+func (s *nodeStrategy) SuggestFees(ctx context.Context, _ time.Time) (Fees, error) {
+	// Returns priority fee per gas
+	tip, err := s.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return Fees{}, fmt.Errorf("failed to get gas tip cap: %w", err)
+	}
+	// Returns priority fee per gas + base fee per gas
+	price, err := s.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return Fees{}, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	return s.capFees(Fees{TipCap: tip, FeeCap: price}), nil
+}
+
+func (s *nodeStrategy) BumpFees(ctx context.Context, prev Fees) (Fees, error) {
+	fresh, err := s.SuggestFees(ctx, time.Time{})
+	if err != nil {
+		return Fees{}, err
+	}
+	return s.bump(prev, fresh)
+}
+
+type feeHistoryStrategy struct {
+	feeLimits
+	client     FeeSuggester
+	blocks     uint64
+	percentile float64
+}
+
+func (s *feeHistoryStrategy) SuggestFees(ctx context.Context, _ time.Time) (Fees, error) {
+	history, err := s.client.FeeHistory(ctx, s.blocks, nil, []float64{s.percentile})
+	if err != nil {
+		return Fees{}, fmt.Errorf("failed to get fee history: %w", err)
+	}
+	if len(history.BaseFee) == 0 {
+		return Fees{}, errors.New("fee history has no base fees")
+	}
+	// Average the tips of the sampled blocks, skipping empty blocks which report no tips.
+	tip, sampled := new(big.Int), int64(0)
+	for i, rewards := range history.Reward {
+		if len(rewards) == 0 || i >= len(history.GasUsedRatio) || history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		tip.Add(tip, rewards[0])
+		sampled++
+	}
+	if sampled == 0 {
+		if tip, err = s.client.SuggestGasTipCap(ctx); err != nil {
+			return Fees{}, fmt.Errorf("failed to get gas tip cap: %w", err)
+		}
+	} else {
+		tip.Div(tip, big.NewInt(sampled))
+	}
+	// The last base fee is the one of the next block. Doubling it keeps the tx
+	// includable through six consecutive full blocks.
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+	feeCap := new(big.Int).Add(new(big.Int).Mul(nextBaseFee, big.NewInt(2)), tip)
+	return s.capFees(Fees{TipCap: tip, FeeCap: feeCap}), nil
+}
+
+func (s *feeHistoryStrategy) BumpFees(ctx context.Context, prev Fees) (Fees, error) {
+	fresh, err := s.SuggestFees(ctx, time.Time{})
+	if err != nil {
+		return Fees{}, err
+	}
+	return s.bump(prev, fresh)
+}
+
+type fixedStrategy struct {
+	feeLimits
+	fees Fees
+}
+
+func (s *fixedStrategy) SuggestFees(context.Context, time.Time) (Fees, error) {
+	return s.capFees(Fees{TipCap: new(big.Int).Set(s.fees.TipCap), FeeCap: new(big.Int).Set(s.fees.FeeCap)}), nil
+}
+
+func (s *fixedStrategy) BumpFees(_ context.Context, prev Fees) (Fees, error) {
+	return s.bump(prev, s.fees)
+}
+
+type aggressiveStrategy struct {
+	GasStrategy
+	feeLimits
+	after      time.Duration
+	tipPercent uint64
+}
+
+func (s *aggressiveStrategy) SuggestFees(ctx context.Context, requestedAt time.Time) (Fees, error) {
+	fees, err := s.GasStrategy.SuggestFees(ctx, requestedAt)
+	if err != nil || requestedAt.IsZero() || time.Since(requestedAt) < s.after {
+		return fees, err
+	}
+	extra := new(big.Int).Mul(fees.TipCap, new(big.Int).SetUint64(s.tipPercent))
+	extra.Div(extra, big.NewInt(100))
+	return s.capFees(Fees{
+		TipCap: new(big.Int).Add(fees.TipCap, extra),
+		FeeCap: new(big.Int).Add(fees.FeeCap, extra),
+	}), nil
+}
+
+// replacementFees returns the fees of a tx replacing pending, the suggested ones raised to at
+// least the minimum replacement bump over the tip and the fee cap of pending, if known.
+func replacementFees(suggested Fees, pending *types.Transaction) Fees {
+	if pending == nil {
+		return suggested
+	}
+	return Fees{
+		TipCap: bigMax(suggested.TipCap, bumpByPercent(pending.GasTipCap(), minReplacementBumpPercent)),
+		FeeCap: bigMax(suggested.FeeCap, bumpByPercent(pending.GasFeeCap(), minReplacementBumpPercent)),
+	}
+}
+
+func gweiToWei(gwei float64) *big.Int {
+	wei, _ := new(big.Float).Mul(big.NewFloat(gwei), big.NewFloat(params.GWei)).Int(nil)
+	return wei
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,155 @@
+package shared
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestReplacementFees(t *testing.T) {
+	suggested := Fees{TipCap: big.NewInt(2_000), FeeCap: big.NewInt(30_000)}
+	tests := []struct {
+		name    string
+		pending *types.Transaction
+		want    Fees
+	}{
+		{
+			name: "unknown pending tx",
+			want: suggested,
+		},
+		{
+			name:    "pending tx below suggestion",
+			pending: types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(1_000), GasFeeCap: big.NewInt(10_000)}),
+			want:    suggested,
+		},
+		{
+			name:    "pending tx sped up above suggestion",
+			pending: types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(10_000), GasFeeCap: big.NewInt(100_000)}),
+			want:    Fees{TipCap: big.NewInt(11_001), FeeCap: big.NewInt(110_001)},
+		},
+		{
+			name:    "only pending tip above suggestion",
+			pending: types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(5_000), GasFeeCap: big.NewInt(20_000)}),
+			want:    Fees{TipCap: big.NewInt(5_501), FeeCap: big.NewInt(30_000)},
+		},
+		{
+			name:    "legacy pending tx",
+			pending: types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(50_000)}),
+			want:    Fees{TipCap: big.NewInt(55_001), FeeCap: big.NewInt(55_001)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := replacementFees(suggested, tt.pending)
+			if got.TipCap.Cmp(tt.want.TipCap) != 0 || got.FeeCap.Cmp(tt.want.FeeCap) != 0 {
+				t.Fatalf("replacementFees() = tip %s, fee cap %s, want tip %s, fee cap %s",
+					got.TipCap, got.FeeCap, tt.want.TipCap, tt.want.FeeCap)
+			}
+		})
+	}
+}
+
+func fees(tip, feeCap int64) Fees {
+	return Fees{TipCap: big.NewInt(tip), FeeCap: big.NewInt(feeCap)}
+}
+
+func TestFeeLimitsCapFees(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits feeLimits
+		in     Fees
+		want   Fees
+	}{
+		{name: "unlimited", in: fees(10, 100), want: fees(10, 100)},
+		{name: "below max fee cap", limits: feeLimits{maxFeeCap: big.NewInt(200)}, in: fees(10, 100), want: fees(10, 100)},
+		{name: "fee cap capped", limits: feeLimits{maxFeeCap: big.NewInt(50)}, in: fees(10, 100), want: fees(10, 50)},
+		{name: "tip capped to fee cap", limits: feeLimits{maxFeeCap: big.NewInt(5)}, in: fees(10, 100), want: fees(5, 5)},
+		{name: "tip above fee cap", in: fees(150, 100), want: fees(100, 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.limits.capFees(tt.in)
+			if got.TipCap.Cmp(tt.want.TipCap) != 0 || got.FeeCap.Cmp(tt.want.FeeCap) != 0 {
+				t.Fatalf("capFees() = %s/%s, want %s/%s", got.TipCap, got.FeeCap, tt.want.TipCap, tt.want.FeeCap)
+			}
+		})
+	}
+}
+
+func TestFeeLimitsBump(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  feeLimits
+		prev    Fees
+		fresh   Fees
+		want    Fees
+		wantErr error
+	}{
+		{
+			// Tip 1000 -> 1101, base fee 9000 -> 9901.
+			name:   "bumps previous fees",
+			limits: feeLimits{bumpPercent: 10},
+			prev:   fees(1_000, 10_000),
+			fresh:  fees(500, 5_000),
+			want:   fees(1_101, 11_002),
+		},
+		{
+			// Tip 2000 -> 2201, base fee 18000 -> 19801.
+			name:   "bumps fresh fees if higher",
+			limits: feeLimits{bumpPercent: 10},
+			prev:   fees(1_000, 10_000),
+			fresh:  fees(2_000, 20_000),
+			want:   fees(2_201, 22_002),
+		},
+		{
+			name:   "bumps tip and base fee separately",
+			limits: feeLimits{bumpPercent: 10},
+			prev:   fees(1_000, 10_000),
+			fresh:  fees(2_000, 5_000),
+			want:   fees(2_201, 12_102),
+		},
+		{
+			name:   "capped below max fee cap",
+			limits: feeLimits{bumpPercent: 10, maxFeeCap: big.NewInt(10_500)},
+			prev:   fees(1_000, 10_000),
+			fresh:  fees(1_000, 10_000),
+			want:   fees(1_101, 10_500),
+		},
+		{
+			name:    "max fee cap reached",
+			limits:  feeLimits{bumpPercent: 10, maxFeeCap: big.NewInt(10_000)},
+			prev:    fees(1_000, 10_000),
+			fresh:   fees(1_000, 10_000),
+			wantErr: ErrMaxFeeCapReached,
+		},
+		{
+			name:    "negative base fee",
+			limits:  feeLimits{bumpPercent: 10},
+			prev:    fees(2_000, 1_000),
+			fresh:   fees(1_000, 10_000),
+			wantErr: errAny,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.limits.bump(tt.prev, tt.fresh)
+			if tt.wantErr != nil {
+				if err == nil || (tt.wantErr != errAny && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("bump() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("bump() failed: %v", err)
+			}
+			if got.TipCap.Cmp(tt.want.TipCap) != 0 || got.FeeCap.Cmp(tt.want.FeeCap) != 0 {
+				t.Fatalf("bump() = %s/%s, want %s/%s", got.TipCap, got.FeeCap, tt.want.TipCap, tt.want.FeeCap)
+			}
+		})
+	}
+}
+
+// errAny matches any error in table tests.
+var errAny = errors.New("any error")
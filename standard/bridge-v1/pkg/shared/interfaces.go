@@ -1,13 +1,40 @@
 package shared
 
 import (
+	"context"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 )
 
+// Backend is the subset of the go-ethereum client API used by the bridge.
+// It is satisfied by *ethclient.Client and *MultiClient.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+	FeeHistory(
+		ctx context.Context,
+		blockCount uint64,
+		lastBlock *big.Int,
+		rewardPercentiles []float64,
+	) (*ethereum.FeeHistory, error)
+}
+
+// RPCCaller is implemented by backends able to make raw JSON-RPC calls, e.g. to methods
+// outside of the eth namespace.
+type RPCCaller interface {
+	CallContext(ctx context.Context, result any, method string, args ...any) error
+}
+
 type GatewayTransactor interface {
 	InitiateTransfer(opts *bind.TransactOpts, _recipient common.Address,
 		amount *big.Int) (*types.Transaction, error)
@@ -15,6 +42,10 @@ type GatewayTransactor interface {
 		_amount *big.Int, _counterpartyIdx *big.Int) (*types.Transaction, error)
 }
 
+type GatewayCaller interface {
+	TransferFinalizedIdx(opts *bind.CallOpts) (*big.Int, error)
+}
+
 type GatewayFilterer interface {
 	ObtainTransferInitiatedEvents(opts *bind.FilterOpts,
 	) ([]TransferInitiatedEvent, error)
@@ -22,4 +53,6 @@ type GatewayFilterer interface {
 	) (TransferInitiatedEvent, error)
 	ObtainTransferFinalizedEvent(opts *bind.FilterOpts, counterpartyIdx *big.Int) (
 		TransferFinalizedEvent, bool, error)
+	WatchTransferInitiatedEvents(opts *bind.WatchOpts, sink chan<- TransferInitiatedEvent,
+	) (event.Subscription, error)
 }
@@ -6,7 +6,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
 	l1g "github.com/primevprotocol/contracts-abi/clients/L1Gateway"
 )
 
@@ -16,7 +16,7 @@ type L1Filterer struct {
 
 func NewL1Filterer(
 	gatewayAddr common.Address,
-	client *ethclient.Client,
+	client bind.ContractFilterer,
 ) (*L1Filterer, error) {
 	f, err := l1g.NewL1gatewayFilterer(gatewayAddr, client)
 	if err != nil {
@@ -42,6 +42,8 @@ func (f *L1Filterer) ObtainTransferInitiatedBySender(
 		Amount:      iter.Event.Amount,
 		TransferIdx: iter.Event.TransferIdx,
 		Chain:       L1,
+		BlockNumber: iter.Event.Raw.BlockNumber,
+		BlockHash:   iter.Event.Raw.BlockHash,
 	}, nil
 }
 
@@ -60,6 +62,8 @@ func (f *L1Filterer) ObtainTransferInitiatedEvents(
 			Amount:      iter.Event.Amount,
 			TransferIdx: iter.Event.TransferIdx,
 			Chain:       L1,
+			BlockNumber: iter.Event.Raw.BlockNumber,
+			BlockHash:   iter.Event.Raw.BlockHash,
 		})
 	}
 	return toReturn, nil
@@ -87,3 +91,45 @@ func (f *L1Filterer) ObtainTransferFinalizedEvent(
 	}
 	return events[0], true, nil
 }
+
+// WatchTransferInitiatedEvents subscribes to transfer initiated events as they are emitted.
+// Events of logs removed by a reorg are delivered as well, so they should only be used as a
+// hint to query the events of a final block range.
+func (f *L1Filterer) WatchTransferInitiatedEvents(
+	opts *bind.WatchOpts,
+	sink chan<- TransferInitiatedEvent,
+) (event.Subscription, error) {
+	logs := make(chan *l1g.L1gatewayTransferInitiated)
+	sub, err := f.WatchTransferInitiated(opts, logs, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch transfer initiated: %w", err)
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case l := <-logs:
+				e := TransferInitiatedEvent{
+					Sender:      l.Sender,
+					Recipient:   l.Recipient,
+					Amount:      l.Amount,
+					TransferIdx: l.TransferIdx,
+					Chain:       L1,
+					BlockNumber: l.Raw.BlockNumber,
+					BlockHash:   l.Raw.BlockHash,
+				}
+				select {
+				case sink <- e:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
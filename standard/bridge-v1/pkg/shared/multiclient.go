@@ -0,0 +1,556 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// endpointProbeInterval is how often the head of every endpoint is polled.
+	endpointProbeInterval = 10 * time.Second
+	// endpointMaxHeadLag is how many blocks an endpoint may trail the highest
+	// head seen across all endpoints and still be considered healthy.
+	endpointMaxHeadLag = 5
+	// endpointMaxErrorRate is the error rate above which an endpoint is considered unhealthy.
+	endpointMaxErrorRate = 0.5
+	// healthSampleWeight is the weight of the latest sample in the latency and error rate averages.
+	healthSampleWeight = 0.2
+	// rpcLimitExceededCode is the JSON-RPC error code nodes and providers use for rate limiting.
+	rpcLimitExceededCode = -32005
+)
+
+// errChainIDMismatch is returned for an endpoint serving another chain than the other endpoints.
+var errChainIDMismatch = errors.New("rpc endpoint serves another chain")
+
+// errEndpointBehind is returned for an endpoint that has not imported the blocks a call is about.
+var errEndpointBehind = errors.New("rpc endpoint has not imported the requested blocks")
+
+// errNotFoundOnEndpoint is returned for an endpoint lacking an object that another endpoint may
+// already have. The call is tried on the next endpoint without counting it as an endpoint error,
+// and ethereum.NotFound is returned if no endpoint has the object.
+var errNotFoundOnEndpoint = errors.New("not found on rpc endpoint")
+
+// endpoint is a single rpc endpoint of a MultiClient along with its health statistics.
+type endpoint struct {
+	rawURL string
+	url    string // Without path and query, which often contain api keys.
+	// dialMu guards client, which is nil until the endpoint was dialed successfully.
+	dialMu    sync.Mutex
+	client    *ethclient.Client
+	latency   time.Duration // Moving average
+	errorRate float64       // Moving average in [0, 1]
+	head      uint64
+	probedAt  time.Time // Time of the last probe
+	probeErr  error     // Error of the last probe, nil if it succeeded
+}
+
+// EndpointHealth is a snapshot of the health statistics of a MultiClient endpoint.
+type EndpointHealth struct {
+	URL       string
+	Latency   time.Duration
+	ErrorRate float64
+	Head      uint64
+	HeadLag   uint64
+	Healthy   bool
+	// Reachable is whether the last probe of the endpoint succeeded, ProbeErr holds its error otherwise.
+	Reachable bool
+	ProbedAt  time.Time
+	ProbeErr  error
+}
+
+// MultiClient is a Backend spreading calls over several rpc endpoints of the same chain.
+// The latency, error rate and head lag of every endpoint are tracked and each call is sent
+// to the best healthy endpoint, failing over to the next best one on transport errors.
+// Errors returned by a node for the call itself, e.g. reverts, are returned as is.
+type MultiClient struct {
+	logger    *slog.Logger
+	mu        sync.Mutex
+	endpoints []*endpoint
+	maxHead   uint64
+	preferred *endpoint
+	chainID   *big.Int // Chain id served by the endpoints, nil until one of them was reached.
+}
+
+// DialMultiClient connects to all urls, which must serve the same chain. Endpoints that
+// cannot be reached yet are kept and dialed again whenever they are probed or called,
+// so that they are used once they become healthy. It fails if an endpoint serves another
+// chain than the others.
+func DialMultiClient(ctx context.Context, logger *slog.Logger, urls []string) (*MultiClient, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no rpc urls provided")
+	}
+	c := &MultiClient{logger: logger}
+	for _, rawURL := range urls {
+		e := &endpoint{rawURL: rawURL, url: redactURL(rawURL)}
+		c.endpoints = append(c.endpoints, e)
+		if _, err := c.conn(ctx, e); err != nil {
+			if errors.Is(err, errChainIDMismatch) {
+				c.Close()
+				return nil, err
+			}
+			c.logger.Warn("rpc endpoint unreachable, will dial it again", "endpoint", e.url, "error", err)
+		}
+	}
+	c.probe(ctx)
+	return c, nil
+}
+
+// conn returns the client of e, dialing the endpoint and checking the chain it serves if
+// that did not succeed before.
+func (c *MultiClient) conn(ctx context.Context, e *endpoint) (*ethclient.Client, error) {
+	e.dialMu.Lock()
+	defer e.dialMu.Unlock()
+	if e.client != nil {
+		return e.client, nil
+	}
+	client, err := ethclient.DialContext(ctx, e.rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial rpc endpoint %s: %w", e.url, redactError(err))
+	}
+	id, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to get chain id of rpc endpoint %s: %w", e.url, redactError(err))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.chainID != nil && c.chainID.Cmp(id) != 0 {
+		client.Close()
+		return nil, fmt.Errorf("%w: %s serves chain %s, expected %s", errChainIDMismatch, e.url, id, c.chainID)
+	}
+	c.chainID = id
+	e.client = client
+	return client, nil
+}
+
+// Start polls the head of every endpoint until ctx is done, which is how the head lag of
+// endpoints is tracked and how unhealthy endpoints recover. The returned channel is
+// closed once polling stopped.
+func (c *MultiClient) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(endpointProbeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			c.probe(ctx)
+		}
+	}()
+	return done
+}
+
+// Close closes the connections to all endpoints.
+func (c *MultiClient) Close() {
+	for _, e := range c.endpoints {
+		e.dialMu.Lock()
+		if e.client != nil {
+			e.client.Close()
+		}
+		e.dialMu.Unlock()
+	}
+}
+
+// Health returns the current health statistics of all endpoints in configuration order.
+func (c *MultiClient) Health() []EndpointHealth {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	health := make([]EndpointHealth, 0, len(c.endpoints))
+	for _, e := range c.endpoints {
+		health = append(health, EndpointHealth{
+			URL:       e.url,
+			Latency:   e.latency,
+			ErrorRate: e.errorRate,
+			Head:      e.head,
+			HeadLag:   c.headLag(e),
+			Healthy:   c.healthy(e),
+			Reachable: !e.probedAt.IsZero() && e.probeErr == nil,
+			ProbedAt:  e.probedAt,
+			ProbeErr:  e.probeErr,
+		})
+	}
+	return health
+}
+
+func (c *MultiClient) probe(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range c.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			start := time.Now()
+			head, err := c.blockNumber(ctx, e)
+			if ctx.Err() != nil {
+				return
+			}
+			c.record(e, time.Since(start), err)
+			if err != nil {
+				c.logger.Warn("rpc endpoint probe failed", "endpoint", e.url, "error", err)
+			}
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			e.probedAt, e.probeErr = time.Now(), redactError(err)
+			if err != nil {
+				return
+			}
+			e.head = head
+			c.maxHead = max(c.maxHead, head)
+		}(e)
+	}
+	wg.Wait()
+}
+
+// blockNumber returns the head of e, dialing it first if needed.
+func (c *MultiClient) blockNumber(ctx context.Context, e *endpoint) (uint64, error) {
+	client, err := c.conn(ctx, e)
+	if err != nil {
+		return 0, err
+	}
+	return client.BlockNumber(ctx)
+}
+
+// record updates the moving averages of e with the outcome of a call.
+func (c *MultiClient) record(e *endpoint, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sample := 0.0
+	if err != nil {
+		sample = 1
+	}
+	e.errorRate += healthSampleWeight * (sample - e.errorRate)
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency += time.Duration(healthSampleWeight * float64(latency-e.latency))
+	}
+}
+
+// headLag and healthy must be called with c.mu held.
+func (c *MultiClient) headLag(e *endpoint) uint64 {
+	return c.maxHead - e.head
+}
+
+func (c *MultiClient) healthy(e *endpoint) bool {
+	return e.errorRate <= endpointMaxErrorRate && c.headLag(e) <= endpointMaxHeadLag
+}
+
+// ranked returns the endpoints in the order they should be tried: healthy ones first,
+// each group ordered by latency penalized with the error rate. Ties keep configuration order.
+func (c *MultiClient) ranked() []*endpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ranked := slices.Clone(c.endpoints)
+	score := func(e *endpoint) float64 {
+		return float64(e.latency) * (1 + 2*e.errorRate)
+	}
+	slices.SortStableFunc(ranked, func(a, b *endpoint) int {
+		if ha, hb := c.healthy(a), c.healthy(b); ha != hb {
+			if ha {
+				return -1
+			}
+			return 1
+		}
+		sa, sb := score(a), score(b)
+		switch {
+		case sa < sb:
+			return -1
+		case sa > sb:
+			return 1
+		}
+		return 0
+	})
+	if best := ranked[0]; best != c.preferred {
+		if c.preferred != nil {
+			c.logger.Info(
+				"preferred rpc endpoint changed",
+				"from", c.preferred.url,
+				"to", best.url,
+				"latency", best.latency,
+				"error_rate", best.errorRate,
+				"head_lag", c.headLag(best),
+				"healthy", c.healthy(best),
+			)
+		}
+		c.preferred = best
+	}
+	return ranked
+}
+
+// isEndpointError reports whether err is caused by the endpoint rather than by the call,
+// in which case the call should be retried on another endpoint.
+func isEndpointError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ethereum.NotFound) {
+		return false
+	}
+	if errors.Is(ClassifyError(err), ErrRateLimited) {
+		return true
+	}
+	// Any other error returned by the node is about the call itself.
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// call runs fn against the endpoints in ranked order until one of them does not fail with an endpoint error.
+func call[T any](ctx context.Context, c *MultiClient, method string, fn func(*ethclient.Client) (T, error)) (T, error) {
+	return callEndpoints(ctx, c, method, func(_ *endpoint, client *ethclient.Client) (T, error) {
+		return fn(client)
+	})
+}
+
+// callEndpoints is call with fn also given the endpoint it runs against.
+func callEndpoints[T any](
+	ctx context.Context,
+	c *MultiClient,
+	method string,
+	fn func(*endpoint, *ethclient.Client) (T, error),
+) (T, error) {
+	var (
+		errs     []error
+		notFound = true
+		zero     T
+	)
+	for _, e := range c.ranked() {
+		start := time.Now()
+		// Endpoints that cannot be dialed are always failed over.
+		client, err := c.conn(ctx, e)
+		if err == nil {
+			var res T
+			res, err = fn(e, client)
+			if errors.Is(err, errNotFoundOnEndpoint) {
+				if ctx.Err() != nil {
+					return zero, ctx.Err()
+				}
+				c.record(e, time.Since(start), nil)
+				continue
+			}
+			if err == nil || !isEndpointError(ctx, err) {
+				c.record(e, time.Since(start), nil)
+				return res, err
+			}
+		}
+		notFound = false
+		c.record(e, time.Since(start), err)
+		c.logger.Warn("rpc call failed, failing over to next endpoint", "method", method, "endpoint", e.url, "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", e.url, redactError(err)))
+	}
+	if notFound {
+		return zero, ethereum.NotFound
+	}
+	return zero, fmt.Errorf("%s failed on all rpc endpoints: %w", method, errors.Join(errs...))
+}
+
+func (c *MultiClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, "eth_chainId", func(client *ethclient.Client) (*big.Int, error) {
+		return client.ChainID(ctx)
+	})
+}
+
+func (c *MultiClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return call(ctx, c, "eth_blockNumber", func(client *ethclient.Client) (uint64, error) {
+		return client.BlockNumber(ctx)
+	})
+}
+
+// HeaderByNumber fails over from endpoints that do not have the header of number because
+// their head is below it.
+func (c *MultiClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return callEndpoints(ctx, c, "eth_getBlockByNumber", func(e *endpoint, client *ethclient.Client) (*types.Header, error) {
+		header, err := client.HeaderByNumber(ctx, number)
+		if errors.Is(err, ethereum.NotFound) && number != nil && number.Sign() >= 0 {
+			if err := c.ensureHead(ctx, e, client, number.Uint64()); err != nil {
+				return nil, err
+			}
+		}
+		return header, err
+	})
+}
+
+// TransactionReceipt tries every endpoint before returning ethereum.NotFound, as endpoints
+// lagging behind may not have imported the block including the tx yet.
+func (c *MultiClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return call(ctx, c, "eth_getTransactionReceipt", func(client *ethclient.Client) (*types.Receipt, error) {
+		receipt, err := client.TransactionReceipt(ctx, txHash)
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, errNotFoundOnEndpoint
+		}
+		return receipt, err
+	})
+}
+
+func (c *MultiClient) TransactionByHash(
+	ctx context.Context,
+	hash common.Hash,
+) (tx *types.Transaction, isPending bool, err error) {
+	type result struct {
+		tx        *types.Transaction
+		isPending bool
+	}
+	res, err := call(ctx, c, "eth_getTransactionByHash", func(client *ethclient.Client) (result, error) {
+		tx, isPending, err := client.TransactionByHash(ctx, hash)
+		return result{tx, isPending}, err
+	})
+	return res.tx, res.isPending, err
+}
+
+// CallContext makes a raw JSON-RPC call, failing over between endpoints like any other call.
+func (c *MultiClient) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	_, err := call(ctx, c, method, func(client *ethclient.Client) (struct{}, error) {
+		return struct{}{}, client.Client().CallContext(ctx, result, method, args...)
+	})
+	return err
+}
+
+func (c *MultiClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return call(ctx, c, "eth_getBalance", func(client *ethclient.Client) (*big.Int, error) {
+		return client.BalanceAt(ctx, account, blockNumber)
+	})
+}
+
+func (c *MultiClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, c, "eth_getCode", func(client *ethclient.Client) ([]byte, error) {
+		return client.CodeAt(ctx, account, blockNumber)
+	})
+}
+
+func (c *MultiClient) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return call(ctx, c, "eth_getCode", func(client *ethclient.Client) ([]byte, error) {
+		return client.PendingCodeAt(ctx, account)
+	})
+}
+
+func (c *MultiClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return call(ctx, c, "eth_getTransactionCount", func(client *ethclient.Client) (uint64, error) {
+		return client.NonceAt(ctx, account, blockNumber)
+	})
+}
+
+func (c *MultiClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return call(ctx, c, "eth_getTransactionCount", func(client *ethclient.Client) (uint64, error) {
+		return client.PendingNonceAt(ctx, account)
+	})
+}
+
+func (c *MultiClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, c, "eth_call", func(client *ethclient.Client) ([]byte, error) {
+		return client.CallContract(ctx, msg, blockNumber)
+	})
+}
+
+func (c *MultiClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, "eth_gasPrice", func(client *ethclient.Client) (*big.Int, error) {
+		return client.SuggestGasPrice(ctx)
+	})
+}
+
+func (c *MultiClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, "eth_maxPriorityFeePerGas", func(client *ethclient.Client) (*big.Int, error) {
+		return client.SuggestGasTipCap(ctx)
+	})
+}
+
+func (c *MultiClient) FeeHistory(
+	ctx context.Context,
+	blockCount uint64,
+	lastBlock *big.Int,
+	rewardPercentiles []float64,
+) (*ethereum.FeeHistory, error) {
+	return call(ctx, c, "eth_feeHistory", func(client *ethclient.Client) (*ethereum.FeeHistory, error) {
+		return client.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+	})
+}
+
+func (c *MultiClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return call(ctx, c, "eth_estimateGas", func(client *ethclient.Client) (uint64, error) {
+		return client.EstimateGas(ctx, msg)
+	})
+}
+
+func (c *MultiClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	_, err := call(ctx, c, "eth_sendRawTransaction", func(client *ethclient.Client) (struct{}, error) {
+		return struct{}{}, client.SendTransaction(ctx, tx)
+	})
+	return err
+}
+
+// FilterLogs fails over from endpoints whose head is below the end of the queried range, as
+// nodes return no logs for blocks they have not imported yet rather than an error. Otherwise
+// a range whose end was obtained from another endpoint could wrongly be found empty.
+func (c *MultiClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return callEndpoints(ctx, c, "eth_getLogs", func(e *endpoint, client *ethclient.Client) ([]types.Log, error) {
+		if q.ToBlock != nil && q.ToBlock.Sign() >= 0 {
+			if err := c.ensureHead(ctx, e, client, q.ToBlock.Uint64()); err != nil {
+				return nil, err
+			}
+		}
+		return client.FilterLogs(ctx, q)
+	})
+}
+
+// ensureHead returns errEndpointBehind if the head of e is below blockNum. The head of e is
+// only obtained again if the one known from the last probe is below blockNum.
+func (c *MultiClient) ensureHead(ctx context.Context, e *endpoint, client *ethclient.Client, blockNum uint64) error {
+	c.mu.Lock()
+	head := e.head
+	c.mu.Unlock()
+	if head >= blockNum {
+		return nil
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	e.head = max(e.head, head)
+	c.maxHead = max(c.maxHead, head)
+	c.mu.Unlock()
+	if head < blockNum {
+		return fmt.Errorf("%w: head %d, requested block %d", errEndpointBehind, head, blockNum)
+	}
+	return nil
+}
+
+func (c *MultiClient) SubscribeFilterLogs(
+	ctx context.Context,
+	q ethereum.FilterQuery,
+	ch chan<- types.Log,
+) (ethereum.Subscription, error) {
+	return call(ctx, c, "eth_subscribe", func(client *ethclient.Client) (ethereum.Subscription, error) {
+		return client.SubscribeFilterLogs(ctx, q, ch)
+	})
+}
+
+// redactURL strips everything but the scheme and host from rawURL so that it can be logged.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "<invalid url>"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// redactError strips the path and query from the url of a transport error, as they often
+// contain api keys.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s %s: %w", urlErr.Op, redactURL(urlErr.URL), urlErr.Err)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceReader is implemented by clients able to report the pending nonce of an account.
+type NonceReader interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out the nonces of an account locally so that several txs can be
+// in flight at once without a PendingNonceAt round trip per tx. Nonces handed out but
+// never used by a tx that reached the node are reused first, so they do not leave gaps.
+// Whenever no nonce is in flight, or after the node reported a nonce as too low, the
+// next nonce is resynced from the pending nonce of the node.
+type NonceManager struct {
+	logger   *slog.Logger
+	client   NonceReader
+	account  common.Address
+	mu       sync.Mutex
+	synced   bool
+	next     uint64
+	inFlight map[uint64]struct{}
+	released []uint64 // Sorted ascending
+}
+
+func NewNonceManager(logger *slog.Logger, client NonceReader, account common.Address) *NonceManager {
+	return &NonceManager{
+		logger:   logger,
+		client:   client,
+		account:  account,
+		inFlight: make(map[uint64]struct{}),
+	}
+}
+
+// Next returns the nonce to use for the next tx. Every nonce returned must be given
+// back with Release once its tx was either included, replaced or abandoned.
+func (m *NonceManager) Next(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.synced {
+		if err := m.sync(ctx); err != nil {
+			return 0, err
+		}
+	}
+	var nonce uint64
+	if len(m.released) > 0 {
+		nonce, m.released = m.released[0], m.released[1:]
+		m.logger.Debug("reusing released nonce", "nonce", nonce)
+	} else {
+		nonce = m.next
+		m.next++
+	}
+	m.inFlight[nonce] = struct{}{}
+	return nonce, nil
+}
+
+// Adopt marks nonce, used by a pending tx sent before the manager was created, as in flight
+// so that it is never handed out while the tx is being waited on. It must be released like
+// any nonce returned by Next.
+func (m *NonceManager) Adopt(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[nonce] = struct{}{}
+	m.next = max(m.next, nonce+1)
+	m.released = slices.DeleteFunc(m.released, func(n uint64) bool { return n == nonce })
+}
+
+// Release gives nonce back. If no tx with the nonce reached the node, it is handed out
+// again before any new one. Once no nonce is in flight, the next call to Next resyncs from the node.
+func (m *NonceManager) Release(nonce uint64, used bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.inFlight[nonce]; !ok {
+		return
+	}
+	delete(m.inFlight, nonce)
+	if !used {
+		i, _ := slices.BinarySearch(m.released, nonce)
+		m.released = slices.Insert(m.released, i, nonce)
+	}
+	if len(m.inFlight) == 0 {
+		m.synced = false
+	}
+}
+
+// InFlight returns the number of nonces handed out and not yet released.
+func (m *NonceManager) InFlight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.inFlight)
+}
+
+// Resync marks the local nonce as stale, e.g. after the node rejected a nonce as too low.
+func (m *NonceManager) Resync() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.synced = false
+}
+
+// sync must be called with m.mu held.
+func (m *NonceManager) sync(ctx context.Context) error {
+	pending, err := m.client.PendingNonceAt(ctx, m.account)
+	if err != nil {
+		return fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+	if len(m.inFlight) == 0 {
+		// Nothing is in flight, the node knows best.
+		m.next = pending
+		m.released = nil
+	} else {
+		// Nonces in flight may not have reached the node yet, never hand them out twice.
+		m.next = max(m.next, pending)
+		m.released = slices.DeleteFunc(m.released, func(n uint64) bool { return n < pending })
+	}
+	m.synced = true
+	m.logger.Debug("nonce synced from node", "pending_nonce", pending, "next_nonce", m.next, "in_flight", len(m.inFlight))
+	return nil
+}
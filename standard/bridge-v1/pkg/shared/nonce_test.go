@@ -0,0 +1,149 @@
+package shared
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// pendingNonce is a NonceReader reporting a settable pending nonce.
+type pendingNonce struct {
+	nonce uint64
+}
+
+func (p *pendingNonce) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return p.nonce, nil
+}
+
+func TestNonceManager(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	type step struct {
+		// op is one of "next", "release", "release_used", "adopt", "resync" or "node".
+		op    string
+		nonce uint64 // Expected by next, given to the others.
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		// wantInFlight is the number of nonces in flight after all steps.
+		wantInFlight int
+	}{
+		{
+			name:         "sequential nonces from the node",
+			steps:        []step{{"node", 5}, {"next", 5}, {"next", 6}, {"next", 7}},
+			wantInFlight: 3,
+		},
+		{
+			name: "unused nonce is reused first",
+			steps: []step{
+				{"node", 5}, {"next", 5}, {"next", 6}, {"next", 7},
+				{"release", 6}, {"next", 6}, {"next", 8},
+			},
+			wantInFlight: 4,
+		},
+		{
+			name: "lowest released nonce is reused first",
+			steps: []step{
+				{"node", 0}, {"next", 0}, {"next", 1}, {"next", 2}, {"next", 3},
+				{"release", 2}, {"release", 1}, {"next", 1}, {"next", 2},
+			},
+			wantInFlight: 4,
+		},
+		{
+			name: "used nonce is not reused",
+			steps: []step{
+				{"node", 5}, {"next", 5}, {"next", 6},
+				{"release_used", 5}, {"next", 7},
+			},
+			wantInFlight: 2,
+		},
+		{
+			name: "resync once nothing is in flight",
+			steps: []step{
+				{"node", 5}, {"next", 5}, {"release", 5},
+				{"node", 9}, {"next", 9},
+			},
+			wantInFlight: 1,
+		},
+		{
+			name: "resync while in flight never hands out a nonce twice",
+			steps: []step{
+				{"node", 5}, {"next", 5}, {"next", 6}, {"next", 7},
+				{"node", 6}, {"resync", 0}, {"next", 8},
+			},
+			wantInFlight: 4,
+		},
+		{
+			name: "resync drops released nonces below the pending nonce",
+			steps: []step{
+				{"node", 5}, {"next", 5}, {"next", 6}, {"next", 7},
+				{"release", 5}, {"node", 6}, {"resync", 0}, {"next", 8},
+			},
+			wantInFlight: 3,
+		},
+		{
+			name: "adopted nonce is skipped",
+			steps: []step{
+				{"node", 5}, {"adopt", 5}, {"next", 6},
+			},
+			wantInFlight: 2,
+		},
+		{
+			name: "adopted nonce is no longer reused",
+			steps: []step{
+				{"node", 5}, {"next", 5}, {"next", 6}, {"release", 5},
+				{"adopt", 5}, {"next", 7},
+			},
+			wantInFlight: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &pendingNonce{}
+			m := NewNonceManager(logger, node, common.Address{})
+			for i, s := range tt.steps {
+				switch s.op {
+				case "node":
+					node.nonce = s.nonce
+				case "next":
+					got, err := m.Next(ctx)
+					if err != nil {
+						t.Fatalf("step %d: Next() failed: %v", i, err)
+					}
+					if got != s.nonce {
+						t.Fatalf("step %d: Next() = %d, want %d", i, got, s.nonce)
+					}
+				case "release":
+					m.Release(s.nonce, false)
+				case "release_used":
+					m.Release(s.nonce, true)
+				case "adopt":
+					m.Adopt(s.nonce)
+				case "resync":
+					m.Resync()
+				}
+			}
+			if got := m.InFlight(); got != tt.wantInFlight {
+				t.Fatalf("InFlight() = %d, want %d", got, tt.wantInFlight)
+			}
+		})
+	}
+}
+
+func TestNonceManagerReleaseUnknownNonce(t *testing.T) {
+	m := NewNonceManager(slog.New(slog.NewTextHandler(io.Discard, nil)), &pendingNonce{nonce: 3}, common.Address{})
+	// Releasing a nonce that was never handed out must not make it available.
+	m.Release(1, false)
+	got, err := m.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+	if got != 3 {
+		t.Fatalf("Next() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,220 @@
+package shared
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	l1g "github.com/primevprotocol/contracts-abi/clients/L1Gateway"
+	sg "github.com/primevprotocol/contracts-abi/clients/SettlementGateway"
+)
+
+// ErrTxReverted is matched by every RevertError.
+var ErrTxReverted = errors.New("tx reverted")
+
+// RevertError is returned for txs that were included but failed.
+type RevertError struct {
+	Receipt *types.Receipt
+	// Reason is the decoded revert reason, empty if it could not be recovered.
+	Reason string
+	// Data is the raw revert data returned by the replay, if any.
+	Data []byte
+}
+
+func (e *RevertError) Error() string {
+	reason := e.Reason
+	if reason == "" {
+		reason = "unknown reason"
+	}
+	return fmt.Sprintf("tx %s reverted in block %s: %s", e.Receipt.TxHash.Hex(), e.Receipt.BlockNumber, reason)
+}
+
+func (e *RevertError) Unwrap() error {
+	return ErrTxReverted
+}
+
+// ErrCallReverted is matched by every CallRevertError.
+var ErrCallReverted = errors.New("call reverted")
+
+// CallRevertError is returned for simulated txs that revert.
+type CallRevertError struct {
+	// Reason is the decoded revert reason, empty if the node did not return one.
+	Reason string
+	Data   []byte
+}
+
+func (e *CallRevertError) Error() string {
+	reason := e.Reason
+	if reason == "" {
+		reason = "unknown reason"
+	}
+	return "call reverted: " + reason
+}
+
+func (e *CallRevertError) Unwrap() error {
+	return ErrCallReverted
+}
+
+// RevertDecoder decodes revert data against the errors declared by a set of contract ABIs.
+type RevertDecoder struct {
+	abis []*abi.ABI
+}
+
+func NewRevertDecoder(abis ...*abi.ABI) *RevertDecoder {
+	return &RevertDecoder{abis: abis}
+}
+
+var gatewayRevertDecoder = sync.OnceValues(newGatewayRevertDecoder)
+
+// GatewayRevertDecoder returns the RevertDecoder of the L1 and settlement gateway contracts.
+func GatewayRevertDecoder() (*RevertDecoder, error) {
+	return gatewayRevertDecoder()
+}
+
+func newGatewayRevertDecoder() (*RevertDecoder, error) {
+	l1ABI, err := l1g.L1gatewayMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse l1 gateway abi: %w", err)
+	}
+	settlementABI, err := sg.SettlementgatewayMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse settlement gateway abi: %w", err)
+	}
+	return NewRevertDecoder(l1ABI, settlementABI), nil
+}
+
+// Decode returns a human readable reason for revert data: the message of a require or
+// revert string, a panic reason, or a custom error of one of the ABIs along with its arguments.
+func (d *RevertDecoder) Decode(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason
+	}
+	if len(data) >= 4 {
+		for _, a := range d.abis {
+			for name, e := range a.Errors {
+				if !bytes.Equal(data[:4], e.ID[:4]) {
+					continue
+				}
+				args, err := e.Inputs.Unpack(data[4:])
+				if err != nil {
+					return name
+				}
+				strs := make([]string, 0, len(args))
+				for _, arg := range args {
+					strs = append(strs, fmt.Sprint(arg))
+				}
+				return name + "(" + strings.Join(strs, ", ") + ")"
+			}
+		}
+	}
+	return "unknown revert data " + hexutil.Encode(data)
+}
+
+// DecodeError returns the decoded revert reason of an error returned by eth_call or eth_estimateGas,
+// along with the raw revert data. It returns false if err is not a revert.
+func (d *RevertDecoder) DecodeError(err error) (string, []byte, bool) {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if s, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(s); decodeErr == nil && len(data) > 0 {
+				return d.Decode(data), data, true
+			}
+		}
+	}
+	if !errors.Is(ClassifyError(err), ErrExecutionReverted) {
+		return "", nil, false
+	}
+	// Some nodes only return the reason as part of the message.
+	_, reason, _ := strings.Cut(err.Error(), "execution reverted")
+	return strings.TrimPrefix(reason, ": "), nil, true
+}
+
+// revertError replays tx, included with a failed receipt, with eth_call against the state at
+// its inclusion block and returns a RevertError holding the decoded reason.
+func (c *ETHClient) revertError(
+	ctx context.Context,
+	from common.Address,
+	tx *types.Transaction,
+	receipt *types.Receipt,
+) *RevertError {
+	revertErr := &RevertError{Receipt: receipt}
+	_, err := c.client.CallContract(ctx, ethereum.CallMsg{
+		From:      from,
+		To:        tx.To(),
+		Gas:       tx.Gas(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	}, receipt.BlockNumber)
+	if err == nil {
+		// The replay ran against the state after the tx, which may differ from the one it
+		// ran against, but running out of gas is the common case of a revert without reason.
+		if receipt.GasUsed >= tx.Gas() {
+			revertErr.Reason = "out of gas"
+		}
+		return revertErr
+	}
+	decoder, decoderErr := GatewayRevertDecoder()
+	if decoderErr != nil {
+		c.logger.Error("failed to create revert decoder", "error", decoderErr)
+		return revertErr
+	}
+	reason, data, ok := decoder.DecodeError(err)
+	if !ok {
+		c.logger.Warn("failed to replay reverted tx", "tx_hash", receipt.TxHash.Hex(), "error", err)
+	}
+	revertErr.Reason, revertErr.Data = reason, data
+	return revertErr
+}
+
+// Simulate runs the tx built by buildTx with eth_call from account against the latest state
+// and returns a *CallRevertError if it reverts. buildTx is called with NoSend set and must
+// not have side effects. No nonce is taken.
+func (c *ETHClient) Simulate(ctx context.Context, account common.Address, buildTx TxSubmitFunc) error {
+	tx, err := buildTx(ctx, &bind.TransactOpts{
+		From:      account,
+		Nonce:     new(big.Int),
+		Signer:    func(_ common.Address, tx *types.Transaction) (*types.Transaction, error) { return tx, nil },
+		GasTipCap: new(big.Int),
+		GasFeeCap: new(big.Int),
+		GasLimit:  c.gas.Limit,
+		Context:   ctx,
+		NoSend:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build tx for simulation: %w", err)
+	}
+	_, err = c.client.CallContract(ctx, ethereum.CallMsg{
+		From:  account,
+		To:    tx.To(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}, nil)
+	if err == nil {
+		return nil
+	}
+	decoder, decoderErr := GatewayRevertDecoder()
+	if decoderErr != nil {
+		return fmt.Errorf("failed to create revert decoder: %w", decoderErr)
+	}
+	reason, data, ok := decoder.DecodeError(err)
+	if !ok {
+		return fmt.Errorf("failed to simulate tx: %w", err)
+	}
+	return &CallRevertError{Reason: reason, Data: data}
+}
@@ -0,0 +1,209 @@
+package shared
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"standard-bridge/pkg/keys"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Signer signs txs on behalf of a single account.
+type Signer interface {
+	// Address returns the account txs are signed for.
+	Address() common.Address
+	// SignTx returns tx signed for chainID.
+	SignTx(ctx context.Context, chainID *big.Int, tx *types.Transaction) (*types.Transaction, error)
+}
+
+// NewTransactOpts returns opts sending txs from the account of signer on chainID. Txs are
+// signed with ctx, which must outlive the opts.
+func NewTransactOpts(ctx context.Context, signer Signer, chainID *big.Int) (*bind.TransactOpts, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+	return &bind.TransactOpts{
+		From: signer.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, chainID, tx)
+		},
+		Context: ctx,
+	}, nil
+}
+
+// LocalSigner signs txs with a private key held in memory.
+type LocalSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// LoadLocalSigner returns a LocalSigner for the plaintext hex key stored in file.
+func LoadLocalSigner(file string) (*LocalSigner, error) {
+	key, err := crypto.LoadECDSA(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load private key: %w", err)
+	}
+	return NewLocalSigner(key), nil
+}
+
+// LoadKeystoreSigner returns a LocalSigner for the key stored in file, a V3 keystore file
+// encrypted with passphrase. The key is only decrypted in memory.
+func LoadKeystoreSigner(file, passphrase string) (*LocalSigner, error) {
+	key, err := keys.DecryptKeystoreFile(file, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalSigner(key), nil
+}
+
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *LocalSigner) SignTx(_ context.Context, chainID *big.Int, tx *types.Transaction) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// Methods of remote signers signing txs without sending them.
+const (
+	// RemoteSignMethodClef is the method of clef.
+	RemoteSignMethodClef = "account_signTransaction"
+	// RemoteSignMethodEth is the method of web3signer and of nodes managing keys.
+	RemoteSignMethodEth = "eth_signTransaction"
+)
+
+// RemoteSignMethods are the supported methods of remote signers.
+var RemoteSignMethods = []string{RemoteSignMethodClef, RemoteSignMethodEth}
+
+// RemoteSigner has txs signed over JSON-RPC by a signer holding the key, e.g. clef or web3signer,
+// so that the key never lives on the host. Every signed tx is checked to be the requested one,
+// signed by the expected account.
+type RemoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+	method  string
+}
+
+// DialRemoteSigner connects to the signer at url, which signs for address with method.
+func DialRemoteSigner(ctx context.Context, url string, address common.Address, method string) (*RemoteSigner, error) {
+	switch method {
+	case RemoteSignMethodClef, RemoteSignMethodEth:
+	default:
+		return nil, fmt.Errorf("unsupported remote sign method %q", method)
+	}
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial remote signer %s: %w", redactURL(url), err)
+	}
+	return &RemoteSigner{client: client, address: address, method: method}, nil
+}
+
+// Close closes the connection to the signer.
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// remoteTxArgs are the tx fields understood by both sign methods.
+type remoteTxArgs struct {
+	From                 common.Address    `json:"from"`
+	To                   *common.Address   `json:"to,omitempty"`
+	Gas                  hexutil.Uint64    `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big      `json:"value"`
+	Nonce                hexutil.Uint64    `json:"nonce"`
+	Data                 hexutil.Bytes     `json:"data"`
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+	ChainID              *hexutil.Big      `json:"chainId"`
+}
+
+func (s *RemoteSigner) SignTx(ctx context.Context, chainID *big.Int, tx *types.Transaction) (*types.Transaction, error) {
+	args := remoteTxArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	default:
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+
+	var result json.RawMessage
+	if err := s.client.CallContext(ctx, &result, s.method, args); err != nil {
+		return nil, fmt.Errorf("remote signer failed to sign tx: %w", err)
+	}
+	raw, err := decodeSignResult(result)
+	if err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("failed to decode tx signed by remote signer: %w", err)
+	}
+
+	ethSigner := types.LatestSignerForChainID(chainID)
+	if ethSigner.Hash(signed) != ethSigner.Hash(tx) {
+		return nil, errors.New("remote signer signed a different tx than requested")
+	}
+	sender, err := types.Sender(ethSigner, signed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender of tx signed by remote signer: %w", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("remote signer signed tx with %s instead of %s", sender.Hex(), s.address.Hex())
+	}
+	return signed, nil
+}
+
+// decodeSignResult returns the raw signed tx of a sign method result, which is either the
+// raw tx or an object holding it along with the decoded tx.
+func decodeSignResult(result json.RawMessage) ([]byte, error) {
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(result, &raw); err == nil {
+		return raw, nil
+	}
+	var obj struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := json.Unmarshal(result, &obj); err != nil || len(obj.Raw) == 0 {
+		return nil, fmt.Errorf("unexpected remote signer result: %s", result)
+	}
+	return obj.Raw, nil
+}
@@ -0,0 +1,144 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrPendingTxUnknown is reported for pending nonces whose tx could not be found.
+var ErrPendingTxUnknown = errors.New("pending tx unknown")
+
+// PendingTransactions returns the pending txs of account in the node's tx pool, keyed by
+// nonce. It requires the txpool_contentFrom method, which geth and erigon expose.
+func (c *ETHClient) PendingTransactions(ctx context.Context, account common.Address) (map[uint64]*types.Transaction, error) {
+	caller, ok := c.client.(RPCCaller)
+	if !ok {
+		return nil, errors.New("backend does not support raw rpc calls")
+	}
+	var content struct {
+		Pending map[string]*types.Transaction `json:"pending"`
+	}
+	if err := caller.CallContext(ctx, &content, "txpool_contentFrom", account); err != nil {
+		return nil, fmt.Errorf("failed to get tx pool content: %w", err)
+	}
+	txs := make(map[uint64]*types.Transaction, len(content.Pending))
+	for nonceStr, tx := range content.Pending {
+		nonce, err := strconv.ParseUint(nonceStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nonce %q in tx pool content: %w", nonceStr, err)
+		}
+		txs[nonce] = tx
+	}
+	return txs, nil
+}
+
+// PendingTxes are the txs of an account pending in the node's tx pool.
+type PendingTxes struct {
+	// From is the nonce of the next tx to be included and To the next nonce after the
+	// pending txs, nonces in [From, To) are pending.
+	From, To uint64
+	// Txs holds the pending txs that could be found, keyed by nonce.
+	Txs map[uint64]*types.Transaction
+}
+
+// FindPendingTxes returns the pending txs of account. They are looked up in the node's tx
+// pool and among known, the hashes of txs the caller sent itself, for nodes that do not
+// expose their pool.
+func (c *ETHClient) FindPendingTxes(ctx context.Context, account common.Address, known []common.Hash) (PendingTxes, error) {
+	latestNonce, currentNonce, err := c.pendingNonceRange(ctx, account)
+	if err != nil {
+		return PendingTxes{}, err
+	}
+	pending := PendingTxes{From: latestNonce, To: currentNonce}
+	if currentNonce <= latestNonce {
+		return pending, nil
+	}
+
+	pending.Txs, err = c.PendingTransactions(ctx, account)
+	if err != nil {
+		c.logger.Warn("failed to list pending transactions, falling back to known ones", "error", err)
+		pending.Txs = make(map[uint64]*types.Transaction)
+	}
+	for _, hash := range known {
+		tx, isPending, err := c.client.TransactionByHash(ctx, hash)
+		if err != nil || !isPending {
+			continue
+		}
+		if _, ok := pending.Txs[tx.Nonce()]; !ok {
+			pending.Txs[tx.Nonce()] = tx
+		}
+	}
+	return pending, nil
+}
+
+// SpeedUpPendingTxes rebroadcasts every pending tx of the account, as found by FindPendingTxes,
+// with the same calldata and bumped fees and waits for each of them to be included, lowest
+// nonce first. It stops at the first nonce that cannot be sped up and returns a report for
+// every nonce it attempted.
+func (c *ETHClient) SpeedUpPendingTxes(
+	ctx context.Context,
+	signer Signer,
+	known []common.Hash,
+) ([]ReplaceReport, error) {
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain id: %w", err)
+	}
+	pending, err := c.FindPendingTxes(ctx, signer.Address(), known)
+	if err != nil {
+		return nil, err
+	}
+	if pending.To <= pending.From {
+		c.logger.Info("no pending transactions to speed up")
+		return nil, nil
+	}
+
+	var reports []ReplaceReport
+	for nonce := pending.From; nonce < pending.To; nonce++ {
+		report := ReplaceReport{Nonce: nonce, Status: TxReplaceFailed, Err: ErrPendingTxUnknown}
+		if tx, ok := pending.Txs[nonce]; ok {
+			report = c.speedUpTx(ctx, signer, chainID, tx)
+		}
+		reports = append(reports, report)
+		if report.Status == TxReplaceFailed {
+			return reports, fmt.Errorf("failed to speed up transaction with nonce %d: %w", nonce, report.Err)
+		}
+	}
+	return reports, nil
+}
+
+// speedUpTx replaces tx with a dynamic fee tx making the same call with bumped fees.
+func (c *ETHClient) speedUpTx(
+	ctx context.Context,
+	signer Signer,
+	chainID *big.Int,
+	tx *types.Transaction,
+) ReplaceReport {
+	fees, err := c.strategy.BumpFees(ctx, Fees{TipCap: tx.GasTipCap(), FeeCap: tx.GasFeeCap()})
+	if err != nil {
+		return ReplaceReport{Nonce: tx.Nonce(), Status: TxReplaceFailed, Err: fmt.Errorf("failed to bump fees: %w", err)}
+	}
+	c.logger.Info(
+		"speeding up pending transaction",
+		"nonce", tx.Nonce(),
+		"tx_hash", tx.Hash().Hex(),
+		"gas_tip", tx.GasTipCap().String(),
+		"gas_fee_cap", tx.GasFeeCap().String(),
+	)
+	return c.replaceNonce(ctx, signer, chainID, &types.DynamicFeeTx{
+		Nonce:      tx.Nonce(),
+		GasTipCap:  fees.TipCap,
+		GasFeeCap:  fees.FeeCap,
+		Gas:        tx.Gas(),
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	})
+}
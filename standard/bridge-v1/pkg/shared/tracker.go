@@ -0,0 +1,108 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// txTrackerPollInterval is how often the receipts of tracked txs are polled, same as bind.WaitMined.
+const txTrackerPollInterval = time.Second
+
+// ReceiptReader is implemented by clients able to fetch tx receipts.
+type ReceiptReader interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// TxAttempt is one of the txs sent for a nonce, each replacing the previous one with higher fees.
+type TxAttempt struct {
+	Attempt   int
+	Hash      common.Hash
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+	tx        *types.Transaction
+}
+
+// MinedTx is the outcome of WaitMinedWithRetry.
+type MinedTx struct {
+	Receipt *types.Receipt
+	// Attempt is the attempt whose tx was included, which is not necessarily the last one.
+	Attempt TxAttempt
+	// Attempts are all txs sent for the nonce, in order.
+	Attempts []TxAttempt
+}
+
+// TxTracker keeps every tx sent for a nonce and waits for any of them to be included.
+// Only one of them can be, so whichever receipt shows up first is the outcome of all.
+type TxTracker struct {
+	logger   *slog.Logger
+	client   ReceiptReader
+	mu       sync.Mutex
+	attempts []TxAttempt
+}
+
+func NewTxTracker(logger *slog.Logger, client ReceiptReader) *TxTracker {
+	return &TxTracker{logger: logger, client: client}
+}
+
+// Add tracks tx, sent on the given attempt.
+func (t *TxTracker) Add(attempt int, tx *types.Transaction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attempts = append(t.attempts, TxAttempt{
+		Attempt:   attempt,
+		Hash:      tx.Hash(),
+		GasTipCap: tx.GasTipCap(),
+		GasFeeCap: tx.GasFeeCap(),
+		tx:        tx,
+	})
+}
+
+// Attempts returns the txs tracked so far, in the order they were added.
+func (t *TxTracker) Attempts() []TxAttempt {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TxAttempt(nil), t.attempts...)
+}
+
+// Check returns the outcome of the first tracked tx found to be included, or nil if none is.
+func (t *TxTracker) Check(ctx context.Context) (*MinedTx, error) {
+	attempts := t.Attempts()
+	// Newer attempts are more likely to be included, check them first.
+	for i := len(attempts) - 1; i >= 0; i-- {
+		receipt, err := t.client.TransactionReceipt(ctx, attempts[i].Hash)
+		switch {
+		case err == nil:
+			return &MinedTx{Receipt: receipt, Attempt: attempts[i], Attempts: attempts}, nil
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case !errors.Is(err, ethereum.NotFound):
+			t.logger.Debug("failed to get receipt", "tx_hash", attempts[i].Hash.Hex(), "error", err)
+		}
+	}
+	return nil, nil
+}
+
+// Wait polls the receipts of all tracked txs until one of them is found or ctx is done.
+func (t *TxTracker) Wait(ctx context.Context) (*MinedTx, error) {
+	ticker := time.NewTicker(txTrackerPollInterval)
+	defer ticker.Stop()
+	for {
+		mined, err := t.Check(ctx)
+		if err != nil || mined != nil {
+			return mined, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,6 +2,7 @@ package shared
 
 import (
 	"math/big"
+	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -30,6 +31,9 @@ type TransferInitiatedEvent struct {
 	Amount      *big.Int
 	TransferIdx *big.Int
 	Chain       Chain
+	// Location of the event on the source chain, used to retract it on reorgs.
+	BlockNumber uint64
+	BlockHash   common.Hash
 }
 
 func (t TransferInitiatedEvent) String() string {
@@ -37,7 +41,8 @@ func (t TransferInitiatedEvent) String() string {
 		" Recipient: " + t.Recipient.String() +
 		" Amount: " + t.Amount.String() +
 		" TransferIdx: " + t.TransferIdx.String() +
-		" Chain: " + t.Chain.String()
+		" Chain: " + t.Chain.String() +
+		" BlockNumber: " + strconv.FormatUint(t.BlockNumber, 10)
 }
 
 type TransferFinalizedEvent struct {
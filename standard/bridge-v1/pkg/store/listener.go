@@ -0,0 +1,242 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"standard-bridge/pkg/shared"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// blockHashRetention is the number of most recent handled block hashes kept per listener.
+// It bounds how deep a reorg can be traced back to a common ancestor.
+const blockHashRetention = 1024
+
+// Reorg records a chain reorganization detected by a listener.
+type Reorg struct {
+	ChainID        *big.Int
+	GatewayAddr    common.Address
+	DetectedAt     time.Time
+	HandledBlock   uint64
+	CommonAncestor uint64
+	OldHash        common.Hash
+	NewHash        common.Hash
+}
+
+// HandledBlock is the hash of a block the listener handled a block range up to.
+type HandledBlock struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// LastHandledBlock returns the last block handled by the listener of the given
+// gateway on the given chain. The bool is false if no checkpoint exists yet.
+func (s *Store) LastHandledBlock(
+	ctx context.Context,
+	chainID *big.Int,
+	gatewayAddr common.Address,
+) (uint64, bool, error) {
+	var blockNum uint64
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT block_num FROM listener_checkpoints WHERE chain_id = ? AND gateway_addr = ?`,
+		chainID.String(), gatewayAddr.Hex(),
+	).Scan(&blockNum)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to query listener checkpoint: %w", err)
+	}
+	return blockNum, true, nil
+}
+
+// SaveHandledEvents atomically enqueues events as seen transfers, remembers blockHash as the
+// hash of blockNum and advances the listener checkpoint to blockNum.
+// Events already in the queue are left untouched.
+func (s *Store) SaveHandledEvents(
+	ctx context.Context,
+	chainID *big.Int,
+	gatewayAddr common.Address,
+	events []shared.TransferInitiatedEvent,
+	blockNum uint64,
+	blockHash common.Hash,
+) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin db tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	now := time.Now().Unix()
+	for _, e := range events {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO transfers (
+				src_chain_id, transfer_idx, src_chain, sender, recipient, amount,
+				src_block_num, src_block_hash, status, tx_hash, attempts, last_error, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '', 0, '', ?, ?)
+			ON CONFLICT (src_chain_id, transfer_idx) DO NOTHING`,
+			chainID.String(), e.TransferIdx.String(), int(e.Chain), e.Sender.Hex(), e.Recipient.Hex(),
+			e.Amount.String(), e.BlockNumber, e.BlockHash.Hex(), TransferSeen, now, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert transfer %s: %w", e.TransferIdx, err)
+		}
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO listener_blocks (chain_id, gateway_addr, block_num, block_hash)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (chain_id, gateway_addr, block_num) DO UPDATE SET block_hash = excluded.block_hash`,
+		chainID.String(), gatewayAddr.Hex(), blockNum, blockHash.Hex(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert listener block hash: %w", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM listener_blocks WHERE chain_id = ? AND gateway_addr = ? AND block_num NOT IN (
+			SELECT block_num FROM listener_blocks WHERE chain_id = ? AND gateway_addr = ?
+			ORDER BY block_num DESC LIMIT ?
+		)`,
+		chainID.String(), gatewayAddr.Hex(), chainID.String(), gatewayAddr.Hex(), blockHashRetention,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prune listener block hashes: %w", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO listener_checkpoints (chain_id, gateway_addr, block_num, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (chain_id, gateway_addr) DO UPDATE SET
+			block_num = excluded.block_num,
+			updated_at = excluded.updated_at`,
+		chainID.String(), gatewayAddr.Hex(), blockNum, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert listener checkpoint: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit db tx: %w", err)
+	}
+	return nil
+}
+
+// HandledBlocks returns the remembered handled blocks at or below blockNum, most recent first.
+func (s *Store) HandledBlocks(
+	ctx context.Context,
+	chainID *big.Int,
+	gatewayAddr common.Address,
+	blockNum uint64,
+) ([]HandledBlock, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT block_num, block_hash FROM listener_blocks
+		WHERE chain_id = ? AND gateway_addr = ? AND block_num <= ?
+		ORDER BY block_num DESC`,
+		chainID.String(), gatewayAddr.Hex(), blockNum,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listener block hashes: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []HandledBlock
+	for rows.Next() {
+		var (
+			b    HandledBlock
+			hash string
+		)
+		if err := rows.Scan(&b.Number, &hash); err != nil {
+			return nil, fmt.Errorf("failed to scan listener block hash: %w", err)
+		}
+		b.Hash = common.HexToHash(hash)
+		blocks = append(blocks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate listener block hashes: %w", err)
+	}
+	return blocks, nil
+}
+
+// RollbackListener rewinds the listener checkpoint to the common ancestor of a reorg and
+// records the reorg. Transfers initiated after the common ancestor that are still only
+// seen are retracted, they are re-emitted once the listener re-handles the new canonical
+// blocks. Transfers past seen can no longer be retracted and are returned as in flight.
+func (s *Store) RollbackListener(
+	ctx context.Context,
+	reorg Reorg,
+) (retracted, inFlight []Transfer, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin db tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT `+transferColumns+` FROM transfers WHERE src_chain_id = ? AND src_block_num > ? ORDER BY id`,
+		reorg.ChainID.String(), reorg.CommonAncestor,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query transfers after common ancestor: %w", err)
+	}
+	affected, err := scanTransfers(rows)
+	rows.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, t := range affected {
+		if t.Status == TransferSeen {
+			retracted = append(retracted, t)
+		} else {
+			inFlight = append(inFlight, t)
+		}
+	}
+
+	stmts := []struct {
+		query string
+		args  []any
+	}{{
+		`DELETE FROM transfers WHERE src_chain_id = ? AND src_block_num > ? AND status = ?`,
+		[]any{reorg.ChainID.String(), reorg.CommonAncestor, TransferSeen},
+	}, {
+		`DELETE FROM listener_blocks WHERE chain_id = ? AND gateway_addr = ? AND block_num > ?`,
+		[]any{reorg.ChainID.String(), reorg.GatewayAddr.Hex(), reorg.CommonAncestor},
+	}, {
+		`UPDATE listener_checkpoints SET block_num = ?, updated_at = ? WHERE chain_id = ? AND gateway_addr = ?`,
+		[]any{reorg.CommonAncestor, time.Now().Unix(), reorg.ChainID.String(), reorg.GatewayAddr.Hex()},
+	}, {
+		`INSERT INTO reorgs (
+			chain_id, gateway_addr, detected_at, handled_block, common_ancestor,
+			old_hash, new_hash, retracted, in_flight
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		[]any{
+			reorg.ChainID.String(), reorg.GatewayAddr.Hex(), reorg.DetectedAt.Unix(), reorg.HandledBlock,
+			reorg.CommonAncestor, reorg.OldHash.Hex(), reorg.NewHash.Hex(), len(retracted), len(inFlight),
+		},
+	}}
+	for _, stmt := range stmts {
+		if _, err = tx.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			return nil, nil, fmt.Errorf("failed to roll back listener: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit db tx: %w", err)
+	}
+	return retracted, inFlight, nil
+}
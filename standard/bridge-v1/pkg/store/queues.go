@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// QueuePause records why and since when the queue of transfers from a chain is paused.
+type QueuePause struct {
+	Reason   string
+	PausedAt time.Time
+}
+
+// PauseQueue pauses the queue of transfers originating from srcChainID, so that it survives
+// restarts. Pausing a paused queue keeps the original pause.
+func (s *Store) PauseQueue(ctx context.Context, srcChainID *big.Int, reason string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO paused_queues (src_chain_id, reason, paused_at) VALUES (?, ?, ?)
+		ON CONFLICT (src_chain_id) DO NOTHING`,
+		srcChainID.String(), reason, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to pause queue: %w", err)
+	}
+	return nil
+}
+
+// ResumeQueue resumes the queue of transfers originating from srcChainID.
+func (s *Store) ResumeQueue(ctx context.Context, srcChainID *big.Int) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM paused_queues WHERE src_chain_id = ?`, srcChainID.String())
+	if err != nil {
+		return fmt.Errorf("failed to resume queue: %w", err)
+	}
+	return nil
+}
+
+// QueuePaused returns the pause of the queue of transfers originating from srcChainID.
+// The bool is false if the queue is not paused.
+func (s *Store) QueuePaused(ctx context.Context, srcChainID *big.Int) (QueuePause, bool, error) {
+	var (
+		pause    QueuePause
+		pausedAt int64
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT reason, paused_at FROM paused_queues WHERE src_chain_id = ?`,
+		srcChainID.String(),
+	).Scan(&pause.Reason, &pausedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return QueuePause{}, false, nil
+	case err != nil:
+		return QueuePause{}, false, fmt.Errorf("failed to query paused queue: %w", err)
+	}
+	pause.PausedAt = time.Unix(pausedAt, 0)
+	return pause, true, nil
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ScreeningHold is the audit record of a transfer held because its sender or recipient
+// matched a screening list.
+type ScreeningHold struct {
+	SrcChainID  *big.Int
+	TransferIdx *big.Int
+	Sender      common.Address
+	Recipient   common.Address
+	Amount      *big.Int
+	// Party is the matching party of the transfer, "sender" or "recipient".
+	Party   string
+	Address common.Address
+	// List is the kind of list matched, "denylist" or "allowlist", and File the list file
+	// the address is on, empty for addresses missing from all allowlists.
+	List   string
+	File   string
+	Reason string
+	HeldAt time.Time
+}
+
+// HoldScreenedTransfer holds the transfer of hold with its reason as last error, and records
+// hold in the screening audit log, atomically. Only transfers awaiting a finalization tx can
+// be held, so a transfer that is held already fails with ErrInvalidTransition and is not
+// recorded twice.
+func (s *Store) HoldScreenedTransfer(ctx context.Context, hold ScreeningHold) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin db tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	err = transitionTx(ctx, tx, hold.SrcChainID, hold.TransferIdx, nil, func(t *Transfer) TransferStatus {
+		t.LastError = hold.Reason
+		t.HeldBy = HoldScreening
+		return TransferHeld
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO screening_holds (
+			src_chain_id, transfer_idx, sender, recipient, amount, party, address, list, file, reason, held_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		hold.SrcChainID.String(), hold.TransferIdx.String(), hold.Sender.Hex(), hold.Recipient.Hex(),
+		hold.Amount.String(), hold.Party, hold.Address.Hex(), hold.List, hold.File, hold.Reason, hold.HeldAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record screening hold: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit db tx: %w", err)
+	}
+	return nil
+}
+
+// ScreeningHolds returns up to limit screening audit records, most recent first.
+func (s *Store) ScreeningHolds(ctx context.Context, limit int) ([]ScreeningHold, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT src_chain_id, transfer_idx, sender, recipient, amount, party, address, list, file, reason, held_at
+		FROM screening_holds ORDER BY id DESC LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query screening holds: %w", err)
+	}
+	defer rows.Close()
+
+	var holds []ScreeningHold
+	for rows.Next() {
+		var (
+			h                               ScreeningHold
+			srcChainID, transferIdx, amount string
+			sender, recipient, address      string
+			heldAt                          int64
+		)
+		err := rows.Scan(
+			&srcChainID, &transferIdx, &sender, &recipient, &amount,
+			&h.Party, &address, &h.List, &h.File, &h.Reason, &heldAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan screening hold: %w", err)
+		}
+		var ok bool
+		if h.SrcChainID, ok = new(big.Int).SetString(srcChainID, 10); !ok {
+			return nil, fmt.Errorf("invalid src chain id: %s", srcChainID)
+		}
+		if h.TransferIdx, ok = new(big.Int).SetString(transferIdx, 10); !ok {
+			return nil, fmt.Errorf("invalid transfer idx: %s", transferIdx)
+		}
+		if h.Amount, ok = new(big.Int).SetString(amount, 10); !ok {
+			return nil, fmt.Errorf("invalid amount: %s", amount)
+		}
+		h.Sender = common.HexToAddress(sender)
+		h.Recipient = common.HexToAddress(recipient)
+		h.Address = common.HexToAddress(address)
+		h.HeldAt = time.Unix(heldAt, 0)
+		holds = append(holds, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate screening holds: %w", err)
+	}
+	return holds, nil
+}
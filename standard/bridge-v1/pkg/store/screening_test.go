@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestReleaseTransferOnlyExemptsFromTheHoldingCheck(t *testing.T) {
+	ctx := context.Background()
+	srcChainID := big.NewInt(1)
+	tests := []struct {
+		name                  string
+		hold                  func(s *Store, idx *big.Int) error
+		wantReleased          bool
+		wantScreeningReleased bool
+	}{
+		{
+			name: "limits hold",
+			hold: func(s *Store, idx *big.Int) error {
+				return s.MarkTransferHeld(ctx, srcChainID, idx, "finalization limit exceeded")
+			},
+			wantReleased: true,
+		},
+		{
+			name: "screening hold",
+			hold: func(s *Store, idx *big.Int) error {
+				return s.HoldScreenedTransfer(ctx, ScreeningHold{
+					SrcChainID:  srcChainID,
+					TransferIdx: idx,
+					Amount:      big.NewInt(1),
+					Party:       "sender",
+					Address:     common.HexToAddress("0x01"),
+					List:        "denylist",
+					Reason:      "failed screening",
+					HeldAt:      time.Now(),
+				})
+			},
+			wantScreeningReleased: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := openTestStore(t)
+			seedTransfer(t, s, srcChainID, 0)
+			idx := big.NewInt(0)
+			if err := tt.hold(s, idx); err != nil {
+				t.Fatalf("failed to hold transfer: %v", err)
+			}
+			if err := s.ReleaseTransfer(ctx, srcChainID, idx); err != nil {
+				t.Fatalf("failed to release transfer: %v", err)
+			}
+			transfer, err := s.GetTransfer(ctx, srcChainID, idx)
+			if err != nil {
+				t.Fatalf("failed to get transfer: %v", err)
+			}
+			if transfer.Status != TransferSeen {
+				t.Errorf("status = %s, want %s", transfer.Status, TransferSeen)
+			}
+			if transfer.Released != tt.wantReleased || transfer.ScreeningReleased != tt.wantScreeningReleased {
+				t.Errorf("released = %t, screening released = %t, want %t, %t",
+					transfer.Released, transfer.ScreeningReleased, tt.wantReleased, tt.wantScreeningReleased)
+			}
+		})
+	}
+}
+
+func TestHoldScreenedTransferIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	srcChainID := big.NewInt(1)
+	s, _ := openTestStore(t)
+	seedTransfer(t, s, srcChainID, 0)
+	hold := ScreeningHold{
+		SrcChainID:  srcChainID,
+		TransferIdx: big.NewInt(0),
+		Amount:      big.NewInt(1),
+		Party:       "recipient",
+		Address:     common.HexToAddress("0x02"),
+		List:        "denylist",
+		Reason:      "failed screening",
+		HeldAt:      time.Now(),
+	}
+	if err := s.HoldScreenedTransfer(ctx, hold); err != nil {
+		t.Fatalf("failed to hold transfer: %v", err)
+	}
+	// A held transfer cannot be held again, and no second audit record is written.
+	if err := s.HoldScreenedTransfer(ctx, hold); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("holding a held transfer: err = %v, want %v", err, ErrInvalidTransition)
+	}
+	holds, err := s.ScreeningHolds(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to get screening holds: %v", err)
+	}
+	if len(holds) != 1 {
+		t.Fatalf("got %d screening holds, want 1", len(holds))
+	}
+}
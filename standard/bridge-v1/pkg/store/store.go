@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// migrations are applied in order by New. The index of the next migration to apply is
+// tracked with sqlite's user_version pragma, so entries must never be reordered or removed.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS listener_checkpoints (
+		chain_id     TEXT    NOT NULL,
+		gateway_addr TEXT    NOT NULL,
+		block_num    INTEGER NOT NULL,
+		updated_at   INTEGER NOT NULL,
+		PRIMARY KEY (chain_id, gateway_addr)
+	)`,
+	`CREATE TABLE IF NOT EXISTS transfers (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		src_chain_id TEXT    NOT NULL,
+		transfer_idx TEXT    NOT NULL,
+		src_chain    INTEGER NOT NULL,
+		sender       TEXT    NOT NULL,
+		recipient    TEXT    NOT NULL,
+		amount       TEXT    NOT NULL,
+		status       TEXT    NOT NULL,
+		tx_hash      TEXT    NOT NULL,
+		attempts     INTEGER NOT NULL,
+		last_error   TEXT    NOT NULL,
+		created_at   INTEGER NOT NULL,
+		updated_at   INTEGER NOT NULL,
+		UNIQUE (src_chain_id, transfer_idx)
+	)`,
+	`CREATE INDEX IF NOT EXISTS transfers_status ON transfers (src_chain_id, status)`,
+	`ALTER TABLE transfers ADD COLUMN src_block_num INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transfers ADD COLUMN src_block_hash TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE IF NOT EXISTS listener_blocks (
+		chain_id     TEXT    NOT NULL,
+		gateway_addr TEXT    NOT NULL,
+		block_num    INTEGER NOT NULL,
+		block_hash   TEXT    NOT NULL,
+		PRIMARY KEY (chain_id, gateway_addr, block_num)
+	)`,
+	`CREATE TABLE IF NOT EXISTS reorgs (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		chain_id        TEXT    NOT NULL,
+		gateway_addr    TEXT    NOT NULL,
+		detected_at     INTEGER NOT NULL,
+		handled_block   INTEGER NOT NULL,
+		common_ancestor INTEGER NOT NULL,
+		old_hash        TEXT    NOT NULL,
+		new_hash        TEXT    NOT NULL,
+		retracted       INTEGER NOT NULL,
+		in_flight       INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS paused_queues (
+		src_chain_id TEXT    NOT NULL PRIMARY KEY,
+		reason       TEXT    NOT NULL,
+		paused_at    INTEGER NOT NULL
+	)`,
+	`ALTER TABLE transfers ADD COLUMN submitted_at INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transfers ADD COLUMN released INTEGER NOT NULL DEFAULT 0`,
+	`CREATE INDEX IF NOT EXISTS transfers_submitted_at ON transfers (src_chain_id, submitted_at)`,
+	`CREATE TABLE IF NOT EXISTS screening_holds (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		src_chain_id TEXT    NOT NULL,
+		transfer_idx TEXT    NOT NULL,
+		sender       TEXT    NOT NULL,
+		recipient    TEXT    NOT NULL,
+		amount       TEXT    NOT NULL,
+		party        TEXT    NOT NULL,
+		address      TEXT    NOT NULL,
+		list         TEXT    NOT NULL,
+		file         TEXT    NOT NULL,
+		reason       TEXT    NOT NULL,
+		held_at      INTEGER NOT NULL
+	)`,
+	`ALTER TABLE transfers ADD COLUMN held_by TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE transfers ADD COLUMN screening_released INTEGER NOT NULL DEFAULT 0`,
+}
+
+// Store persists relayer state in an embedded sqlite database.
+type Store struct {
+	db *sql.DB
+}
+
+// OpenDB opens (creating if needed) the sqlite database at path.
+func OpenDB(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create db directory: %w", err)
+		}
+	}
+	dsn := "file:" + path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=synchronous(NORMAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db: %w", err)
+	}
+	// sqlite allows a single writer, serialize access to avoid SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// New returns a Store backed by db after applying all pending migrations.
+func New(ctx context.Context, db *sql.DB) (*Store, error) {
+	var version int
+	if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to query schema version: %w", err)
+	}
+	for i := version; i < len(migrations); i++ {
+		if err := applyMigration(ctx, db, i); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// applyMigration applies migration i and bumps the schema version in one db tx, so that a
+// crash cannot leave a migration applied without the version reflecting it.
+func applyMigration(ctx context.Context, db *sql.DB, i int) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin db tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, migrations[i]); err != nil {
+		return fmt.Errorf("failed to apply migration %d: %w", i, err)
+	}
+	// PRAGMA statements do not support bound parameters.
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+		return fmt.Errorf("failed to update schema version to %d: %w", i+1, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit db tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"standard-bridge/pkg/shared"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func openTestStore(t *testing.T) (*Store, *sql.DB) {
+	t.Helper()
+	db, err := OpenDB(filepath.Join(t.TempDir(), "relayer.db"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := New(context.Background(), db)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return s, db
+}
+
+func TestNewAppliesMigrationsOnce(t *testing.T) {
+	_, db := openTestStore(t)
+	ctx := context.Background()
+
+	var version int
+	if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		t.Fatalf("failed to query schema version: %v", err)
+	}
+	if version != len(migrations) {
+		t.Fatalf("schema version = %d, want %d", version, len(migrations))
+	}
+	// Migrations adding columns fail if applied twice.
+	if _, err := New(ctx, db); err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+}
+
+func TestApplyMigrationRollsBackOnFailure(t *testing.T) {
+	_, db := openTestStore(t)
+	ctx := context.Background()
+
+	// Adding a column that exists fails, and the version bump must not be committed either.
+	i := slices.IndexFunc(migrations, func(m string) bool {
+		return strings.Contains(m, "ADD COLUMN released")
+	})
+	if _, err := db.ExecContext(ctx, `PRAGMA user_version = 0`); err != nil {
+		t.Fatalf("failed to reset schema version: %v", err)
+	}
+	if err := applyMigration(ctx, db, i); err == nil {
+		t.Fatal("expected applying a migration twice to fail")
+	}
+	var version int
+	if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		t.Fatalf("failed to query schema version: %v", err)
+	}
+	if version != 0 {
+		t.Fatalf("schema version = %d after failed migration, want 0", version)
+	}
+}
+
+// seedTransfer enqueues a seen transfer with idx originating from srcChainID.
+func seedTransfer(t *testing.T, s *Store, srcChainID *big.Int, idx int64) {
+	t.Helper()
+	event := shared.TransferInitiatedEvent{
+		Sender:      common.HexToAddress("0x01"),
+		Recipient:   common.HexToAddress("0x02"),
+		Amount:      big.NewInt(1),
+		TransferIdx: big.NewInt(idx),
+		Chain:       shared.L1,
+		BlockNumber: uint64(idx),
+	}
+	err := s.SaveHandledEvents(context.Background(), srcChainID, common.HexToAddress("0x03"),
+		[]shared.TransferInitiatedEvent{event}, uint64(idx), common.Hash{})
+	if err != nil {
+		t.Fatalf("failed to seed transfer: %v", err)
+	}
+}
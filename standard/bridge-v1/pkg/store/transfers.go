@@ -0,0 +1,508 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"standard-bridge/pkg/shared"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TransferStatus is the state of a pending transfer finalization. The happy path is
+// seen -> submitted -> mined -> confirmed. Any pending state may move to failed, from
+// where the transfer is retried until it runs out of attempts and is dead-lettered.
+// A transfer found to be finalized on the destination chain may jump straight to confirmed,
+// and one whose finalization would revert is quarantined until an operator looks into it.
+// A transfer exceeding the finalization limits is held until an operator releases it.
+// Operators may also quarantine a transfer, skip it for good, or requeue it as seen unless
+// it was confirmed.
+type TransferStatus string
+
+const (
+	TransferSeen         TransferStatus = "seen"
+	TransferSubmitted    TransferStatus = "submitted"
+	TransferMined        TransferStatus = "mined"
+	TransferConfirmed    TransferStatus = "confirmed"
+	TransferFailed       TransferStatus = "failed"
+	TransferDeadLettered TransferStatus = "dead_lettered"
+	TransferQuarantined  TransferStatus = "quarantined"
+	TransferSkipped      TransferStatus = "skipped"
+	TransferHeld         TransferStatus = "held"
+)
+
+// HoldKind is the check a held transfer failed.
+type HoldKind string
+
+const (
+	HoldLimits    HoldKind = "limits"
+	HoldScreening HoldKind = "screening"
+)
+
+// transferTransitions maps each status to the statuses it may be entered from.
+var transferTransitions = map[TransferStatus][]TransferStatus{
+	TransferSubmitted:    {TransferSeen, TransferSubmitted, TransferFailed},
+	TransferMined:        {TransferSubmitted},
+	TransferConfirmed:    {TransferSeen, TransferSubmitted, TransferMined, TransferFailed},
+	TransferFailed:       {TransferSeen, TransferSubmitted, TransferMined, TransferFailed},
+	TransferDeadLettered: {TransferSeen, TransferSubmitted, TransferMined, TransferFailed},
+	TransferQuarantined:  {TransferSeen, TransferSubmitted, TransferFailed, TransferDeadLettered, TransferHeld},
+	TransferSkipped:      {TransferSeen, TransferFailed, TransferDeadLettered, TransferQuarantined, TransferHeld},
+	TransferHeld:         {TransferSeen, TransferFailed},
+	TransferSeen: {
+		TransferFailed, TransferDeadLettered, TransferQuarantined, TransferSkipped, TransferHeld,
+	},
+}
+
+// pendingTransferStatuses are the statuses a transactor still has to act upon.
+var pendingTransferStatuses = []TransferStatus{
+	TransferSeen,
+	TransferSubmitted,
+	TransferMined,
+	TransferFailed,
+}
+
+// ErrInvalidTransition is returned when a transfer is not in a state
+// from which the requested status can be entered.
+var ErrInvalidTransition = errors.New("invalid transfer status transition")
+
+// Transfer is a persisted TransferInitiatedEvent awaiting finalization on the destination chain.
+type Transfer struct {
+	SrcChainID *big.Int
+	Event      shared.TransferInitiatedEvent
+	Status     TransferStatus
+	TxHash     common.Hash
+	Attempts   int
+	LastError  string
+	// SubmittedAt is when a finalization tx was first sent for the transfer, zero if none was.
+	SubmittedAt time.Time
+	// HeldBy is the check that held the transfer last, empty if it was never held.
+	HeldBy HoldKind
+	// Released is set once an operator released the transfer from the finalization limits.
+	Released bool
+	// ScreeningReleased is set once an operator released the transfer from a screening hold.
+	ScreeningReleased bool
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+func (t Transfer) String() string {
+	return "SrcChainID: " + t.SrcChainID.String() +
+		" TransferIdx: " + t.Event.TransferIdx.String() +
+		" Status: " + string(t.Status) +
+		" TxHash: " + t.TxHash.Hex() +
+		" Attempts: " + fmt.Sprint(t.Attempts)
+}
+
+// PendingTransfers returns transfers originating from srcChainID that still need to be
+// acted upon, oldest first. Failed transfers are only returned once retryDelay has passed
+// since their last update.
+func (s *Store) PendingTransfers(
+	ctx context.Context,
+	srcChainID *big.Int,
+	retryDelay time.Duration,
+) ([]Transfer, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(pendingTransferStatuses)), ", ")
+	args := []any{srcChainID.String()}
+	for _, status := range pendingTransferStatuses {
+		args = append(args, status)
+	}
+	args = append(args, TransferFailed, time.Now().Add(-retryDelay).Unix())
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+transferColumns+` FROM transfers
+		WHERE src_chain_id = ? AND status IN (`+placeholders+`)
+		AND NOT (status = ? AND updated_at > ?)
+		ORDER BY id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending transfers: %w", err)
+	}
+	defer rows.Close()
+	return scanTransfers(rows)
+}
+
+// MarkTransferSubmitted records txHash as the latest finalization tx sent for the transfer.
+// The attempt count is incremented when entering submitted from seen or failed.
+func (s *Store) MarkTransferSubmitted(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	txHash common.Hash,
+) error {
+	return s.transition(ctx, srcChainID, transferIdx, func(t *Transfer) TransferStatus {
+		if t.Status != TransferSubmitted {
+			t.Attempts++
+		}
+		t.TxHash = txHash
+		return TransferSubmitted
+	})
+}
+
+// MarkTransferMined records txHash as the finalization tx included on the destination chain.
+func (s *Store) MarkTransferMined(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	txHash common.Hash,
+) error {
+	return s.transition(ctx, srcChainID, transferIdx, func(t *Transfer) TransferStatus {
+		t.TxHash = txHash
+		return TransferMined
+	})
+}
+
+// MarkTransferConfirmed records that the transfer finalization was observed on the destination chain.
+func (s *Store) MarkTransferConfirmed(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+) error {
+	return s.transition(ctx, srcChainID, transferIdx, func(t *Transfer) TransferStatus {
+		t.LastError = ""
+		return TransferConfirmed
+	})
+}
+
+// MarkTransferFailed records reason as the last error of the transfer. Once the transfer has
+// used maxAttempts attempts it is dead-lettered instead and no longer returned as pending.
+// The resulting status is returned.
+func (s *Store) MarkTransferFailed(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	reason error,
+	maxAttempts int,
+) (TransferStatus, error) {
+	var status TransferStatus
+	err := s.transition(ctx, srcChainID, transferIdx, func(t *Transfer) TransferStatus {
+		// Attempts that failed before a tx was submitted are counted here.
+		if t.Status == TransferSeen || t.Status == TransferFailed {
+			t.Attempts++
+		}
+		t.LastError = reason.Error()
+		status = TransferFailed
+		if t.Attempts >= maxAttempts {
+			status = TransferDeadLettered
+		}
+		return status
+	})
+	if err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// MarkTransferQuarantined records reason as the last error of the transfer and takes it out
+// of the pending transfers without counting an attempt.
+func (s *Store) MarkTransferQuarantined(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	reason error,
+) error {
+	return s.transition(ctx, srcChainID, transferIdx, func(t *Transfer) TransferStatus {
+		t.LastError = reason.Error()
+		return TransferQuarantined
+	})
+}
+
+// MarkTransferHeld records reason as the last error of a transfer exceeding the finalization
+// limits and takes it out of the pending transfers until it is released.
+func (s *Store) MarkTransferHeld(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	reason string,
+) error {
+	return s.transition(ctx, srcChainID, transferIdx, func(t *Transfer) TransferStatus {
+		t.LastError = reason
+		t.HeldBy = HoldLimits
+		return TransferHeld
+	})
+}
+
+// ReleaseTransfer moves a held transfer back to seen, exempting it from the check that held
+// it, the finalization limits or screening.
+func (s *Store) ReleaseTransfer(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+) error {
+	return s.transitionFrom(ctx, srcChainID, transferIdx, []TransferStatus{TransferHeld}, func(t *Transfer) TransferStatus {
+		t.LastError = ""
+		if t.HeldBy == HoldScreening {
+			t.ScreeningReleased = true
+		} else {
+			t.Released = true
+		}
+		return TransferSeen
+	})
+}
+
+// MarkTransferSkipped records reason as the last error of the transfer and takes it out of the
+// pending transfers for good, e.g. because an operator finalized it by other means.
+func (s *Store) MarkTransferSkipped(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	reason string,
+) error {
+	return s.transition(ctx, srcChainID, transferIdx, func(t *Transfer) TransferStatus {
+		t.LastError = reason
+		return TransferSkipped
+	})
+}
+
+// RequeueTransfer moves a transfer that is no longer pending back to seen with no attempts,
+// so that it is finalized again. Confirmed transfers are finalized for good and cannot be requeued.
+func (s *Store) RequeueTransfer(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+) error {
+	var current TransferStatus
+	err := s.transition(ctx, srcChainID, transferIdx, func(t *Transfer) TransferStatus {
+		current = t.Status
+		t.TxHash = common.Hash{}
+		t.Attempts = 0
+		t.LastError = ""
+		return TransferSeen
+	})
+	if errors.Is(err, ErrInvalidTransition) && current == TransferConfirmed {
+		return fmt.Errorf("transfer %s is already finalized on the destination chain, it cannot be requeued: %w",
+			transferIdx, ErrInvalidTransition)
+	}
+	return err
+}
+
+// Transfers returns up to limit transfers originating from srcChainID in any of statuses,
+// oldest first.
+func (s *Store) Transfers(
+	ctx context.Context,
+	srcChainID *big.Int,
+	statuses []TransferStatus,
+	limit int,
+) ([]Transfer, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	args := []any{srcChainID.String()}
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+transferColumns+` FROM transfers
+		WHERE src_chain_id = ? AND status IN (`+placeholders+`)
+		ORDER BY id LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transfers: %w", err)
+	}
+	defer rows.Close()
+	return scanTransfers(rows)
+}
+
+// TransfersSubmittedSince returns the transfers originating from srcChainID whose first
+// finalization tx was sent at or after since, oldest first.
+func (s *Store) TransfersSubmittedSince(
+	ctx context.Context,
+	srcChainID *big.Int,
+	since time.Time,
+) ([]Transfer, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+transferColumns+` FROM transfers
+		WHERE src_chain_id = ? AND submitted_at >= ? AND submitted_at > 0
+		ORDER BY submitted_at`,
+		srcChainID.String(), since.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query submitted transfers: %w", err)
+	}
+	defer rows.Close()
+	return scanTransfers(rows)
+}
+
+// GetTransfer returns the transfer identified by srcChainID and transferIdx.
+func (s *Store) GetTransfer(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+) (Transfer, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+transferColumns+` FROM transfers WHERE src_chain_id = ? AND transfer_idx = ?`,
+		srcChainID.String(), transferIdx.String(),
+	)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("failed to query transfer: %w", err)
+	}
+	defer rows.Close()
+	transfers, err := scanTransfers(rows)
+	if err != nil {
+		return Transfer{}, err
+	}
+	if len(transfers) == 0 {
+		return Transfer{}, fmt.Errorf("transfer %s from chain %s: %w", transferIdx, srcChainID, sql.ErrNoRows)
+	}
+	return transfers[0], nil
+}
+
+// transition applies update to the mutable fields of the transfer and moves it into the
+// status update returns. It fails with ErrInvalidTransition if the current status does not allow it.
+func (s *Store) transition(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	update func(t *Transfer) TransferStatus,
+) error {
+	return s.transitionFrom(ctx, srcChainID, transferIdx, nil, update)
+}
+
+// transitionFrom is transition restricted to transfers in any of from, if not empty.
+func (s *Store) transitionFrom(
+	ctx context.Context,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	from []TransferStatus,
+	update func(t *Transfer) TransferStatus,
+) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin db tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	if err = transitionTx(ctx, tx, srcChainID, transferIdx, from, update); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit db tx: %w", err)
+	}
+	return nil
+}
+
+// transitionTx is transitionFrom within tx, which is left for the caller to commit.
+func transitionTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	srcChainID *big.Int,
+	transferIdx *big.Int,
+	from []TransferStatus,
+	update func(t *Transfer) TransferStatus,
+) error {
+	var (
+		t      Transfer
+		status string
+		txHash string
+	)
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT status, tx_hash, attempts, last_error, held_by, released, screening_released FROM transfers
+		WHERE src_chain_id = ? AND transfer_idx = ?`,
+		srcChainID.String(), transferIdx.String(),
+	).Scan(&status, &txHash, &t.Attempts, &t.LastError, &t.HeldBy, &t.Released, &t.ScreeningReleased)
+	if err != nil {
+		return fmt.Errorf("failed to query transfer %s: %w", transferIdx, err)
+	}
+	t.Status = TransferStatus(status)
+	if txHash != "" {
+		t.TxHash = common.HexToHash(txHash)
+	}
+
+	current := t.Status
+	to := update(&t)
+	if !slices.Contains(transferTransitions[to], current) || (len(from) > 0 && !slices.Contains(from, current)) {
+		return fmt.Errorf("transfer %s: %s -> %s: %w", transferIdx, current, to, ErrInvalidTransition)
+	}
+	txHash = ""
+	if t.TxHash != (common.Hash{}) {
+		txHash = t.TxHash.Hex()
+	}
+	now := time.Now().Unix()
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE transfers SET status = ?, tx_hash = ?, attempts = ?, last_error = ?, held_by = ?, released = ?,
+			screening_released = ?, updated_at = ?,
+			submitted_at = CASE WHEN ? = ? AND submitted_at = 0 THEN ? ELSE submitted_at END
+		WHERE src_chain_id = ? AND transfer_idx = ?`,
+		to, txHash, t.Attempts, t.LastError, t.HeldBy, t.Released, t.ScreeningReleased, now,
+		to, TransferSubmitted, now,
+		srcChainID.String(), transferIdx.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update transfer %s: %w", transferIdx, err)
+	}
+	return nil
+}
+
+const transferColumns = `src_chain_id, transfer_idx, src_chain, sender, recipient, amount,
+	src_block_num, src_block_hash, status, tx_hash, attempts, last_error, submitted_at, held_by, released,
+	screening_released, created_at, updated_at`
+
+func scanTransfers(rows *sql.Rows) ([]Transfer, error) {
+	var transfers []Transfer
+	for rows.Next() {
+		var (
+			t                                 Transfer
+			srcChainID, transferIdx, amount   string
+			sender, recipient, status, txHash string
+			srcBlockHash                      string
+			srcChain                          int
+			submittedAt, createdAt, updatedAt int64
+		)
+		err := rows.Scan(
+			&srcChainID, &transferIdx, &srcChain, &sender, &recipient, &amount,
+			&t.Event.BlockNumber, &srcBlockHash, &status, &txHash, &t.Attempts, &t.LastError,
+			&submittedAt, &t.HeldBy, &t.Released, &t.ScreeningReleased, &createdAt, &updatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transfer: %w", err)
+		}
+		var ok bool
+		if t.SrcChainID, ok = new(big.Int).SetString(srcChainID, 10); !ok {
+			return nil, fmt.Errorf("invalid src chain id: %s", srcChainID)
+		}
+		if t.Event.TransferIdx, ok = new(big.Int).SetString(transferIdx, 10); !ok {
+			return nil, fmt.Errorf("invalid transfer idx: %s", transferIdx)
+		}
+		if t.Event.Amount, ok = new(big.Int).SetString(amount, 10); !ok {
+			return nil, fmt.Errorf("invalid amount: %s", amount)
+		}
+		t.Event.Chain = shared.Chain(srcChain)
+		t.Event.Sender = common.HexToAddress(sender)
+		t.Event.Recipient = common.HexToAddress(recipient)
+		if srcBlockHash != "" {
+			t.Event.BlockHash = common.HexToHash(srcBlockHash)
+		}
+		t.Status = TransferStatus(status)
+		if txHash != "" {
+			t.TxHash = common.HexToHash(txHash)
+		}
+		if submittedAt != 0 {
+			t.SubmittedAt = time.Unix(submittedAt, 0)
+		}
+		t.CreatedAt = time.Unix(createdAt, 0)
+		t.UpdatedAt = time.Unix(updatedAt, 0)
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transfers: %w", err)
+	}
+	return transfers, nil
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+var allTransferStatuses = []TransferStatus{
+	TransferSeen,
+	TransferSubmitted,
+	TransferMined,
+	TransferConfirmed,
+	TransferFailed,
+	TransferDeadLettered,
+	TransferQuarantined,
+	TransferSkipped,
+	TransferHeld,
+}
+
+// setTransferStatus forces the status of a seeded transfer, bypassing the transition checks.
+func setTransferStatus(t *testing.T, db *sql.DB, srcChainID *big.Int, idx int64, status TransferStatus) {
+	t.Helper()
+	_, err := db.ExecContext(
+		context.Background(),
+		`UPDATE transfers SET status = ? WHERE src_chain_id = ? AND transfer_idx = ?`,
+		status, srcChainID.String(), big.NewInt(idx).String(),
+	)
+	if err != nil {
+		t.Fatalf("failed to set transfer status: %v", err)
+	}
+}
+
+func TestTransferTransitions(t *testing.T) {
+	tests := []struct {
+		to   TransferStatus
+		from []TransferStatus
+	}{
+		{TransferSeen, []TransferStatus{
+			TransferFailed, TransferDeadLettered, TransferQuarantined, TransferSkipped, TransferHeld,
+		}},
+		{TransferSubmitted, []TransferStatus{TransferSeen, TransferSubmitted, TransferFailed}},
+		{TransferMined, []TransferStatus{TransferSubmitted}},
+		{TransferConfirmed, []TransferStatus{TransferSeen, TransferSubmitted, TransferMined, TransferFailed}},
+		{TransferFailed, []TransferStatus{TransferSeen, TransferSubmitted, TransferMined, TransferFailed}},
+		{TransferDeadLettered, []TransferStatus{TransferSeen, TransferSubmitted, TransferMined, TransferFailed}},
+		{TransferQuarantined, []TransferStatus{
+			TransferSeen, TransferSubmitted, TransferFailed, TransferDeadLettered, TransferHeld,
+		}},
+		{TransferSkipped, []TransferStatus{
+			TransferSeen, TransferFailed, TransferDeadLettered, TransferQuarantined, TransferHeld,
+		}},
+		{TransferHeld, []TransferStatus{TransferSeen, TransferFailed}},
+	}
+
+	ctx := context.Background()
+	srcChainID := big.NewInt(1)
+	s, db := openTestStore(t)
+	seedTransfer(t, s, srcChainID, 1)
+	idx := big.NewInt(1)
+
+	for _, tt := range tests {
+		for _, current := range allTransferStatuses {
+			t.Run(string(current)+"->"+string(tt.to), func(t *testing.T) {
+				setTransferStatus(t, db, srcChainID, 1, current)
+				err := s.transition(ctx, srcChainID, idx, func(*Transfer) TransferStatus { return tt.to })
+
+				want := slices.Contains(tt.from, current)
+				switch {
+				case want && err != nil:
+					t.Fatalf("transition() error = %v, want allowed", err)
+				case !want && !errors.Is(err, ErrInvalidTransition):
+					t.Fatalf("transition() error = %v, want %v", err, ErrInvalidTransition)
+				}
+
+				got, err := s.GetTransfer(ctx, srcChainID, idx)
+				if err != nil {
+					t.Fatalf("failed to get transfer: %v", err)
+				}
+				wantStatus := current
+				if want {
+					wantStatus = tt.to
+				}
+				if got.Status != wantStatus {
+					t.Fatalf("status = %s, want %s", got.Status, wantStatus)
+				}
+			})
+		}
+	}
+}
+
+func TestTransitionFromRestrictsCurrentStatus(t *testing.T) {
+	ctx := context.Background()
+	srcChainID := big.NewInt(1)
+	s, db := openTestStore(t)
+	seedTransfer(t, s, srcChainID, 1)
+	idx := big.NewInt(1)
+
+	// Failed transfers may re-enter seen in general, but not when only held ones may.
+	setTransferStatus(t, db, srcChainID, 1, TransferFailed)
+	err := s.transitionFrom(ctx, srcChainID, idx, []TransferStatus{TransferHeld}, func(*Transfer) TransferStatus {
+		return TransferSeen
+	})
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("transitionFrom() error = %v, want %v", err, ErrInvalidTransition)
+	}
+}
+
+func TestRequeueTransfer(t *testing.T) {
+	requeueable := []TransferStatus{
+		TransferFailed, TransferDeadLettered, TransferQuarantined, TransferSkipped, TransferHeld,
+	}
+
+	ctx := context.Background()
+	srcChainID := big.NewInt(1)
+	s, db := openTestStore(t)
+	seedTransfer(t, s, srcChainID, 1)
+	idx := big.NewInt(1)
+
+	for _, current := range allTransferStatuses {
+		t.Run(string(current), func(t *testing.T) {
+			setTransferStatus(t, db, srcChainID, 1, current)
+			_, err := db.ExecContext(ctx, `UPDATE transfers SET attempts = 3, last_error = 'reverted'`)
+			if err != nil {
+				t.Fatalf("failed to set transfer attempts: %v", err)
+			}
+
+			err = s.RequeueTransfer(ctx, srcChainID, idx)
+			want := slices.Contains(requeueable, current)
+			switch {
+			case want && err != nil:
+				t.Fatalf("RequeueTransfer() error = %v, want allowed", err)
+			case !want && !errors.Is(err, ErrInvalidTransition):
+				t.Fatalf("RequeueTransfer() error = %v, want %v", err, ErrInvalidTransition)
+			}
+
+			got, err := s.GetTransfer(ctx, srcChainID, idx)
+			if err != nil {
+				t.Fatalf("failed to get transfer: %v", err)
+			}
+			if !want {
+				if got.Status != current || got.Attempts != 3 {
+					t.Fatalf("transfer = %s with %d attempts, want unchanged", got.Status, got.Attempts)
+				}
+				return
+			}
+			if got.Status != TransferSeen || got.Attempts != 0 || got.LastError != "" {
+				t.Fatalf("transfer = %s with %d attempts and error %q, want seen with none",
+					got.Status, got.Attempts, got.LastError)
+			}
+		})
+	}
+}
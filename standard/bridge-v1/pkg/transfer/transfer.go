@@ -3,6 +3,7 @@ package transfer
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
@@ -14,11 +15,9 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	l1g "github.com/primevprotocol/contracts-abi/clients/L1Gateway"
 	sg "github.com/primevprotocol/contracts-abi/clients/SettlementGateway"
-	"golang.org/x/crypto/sha3"
 )
 
 type Transfer struct {
@@ -26,7 +25,7 @@ type Transfer struct {
 
 	amount      *big.Int
 	destAddress common.Address
-	privateKey  *ecdsa.PrivateKey
+	signer      shared.Signer
 
 	srcClient     *shared.ETHClient
 	srcChainID    *big.Int
@@ -47,10 +46,12 @@ func NewTransferToSettlement(
 	l1RPCUrl string,
 	l1ContractAddr common.Address,
 	settlementContractAddr common.Address,
+	chains *shared.ChainRegistry,
 ) (*Transfer, error) {
-	t := &Transfer{logger: logger}
+	t := &Transfer{logger: logger, signer: shared.NewLocalSigner(privateKey)}
+	t.logger.Info("signing address used for InitiateTransfer tx on source chain", "address", t.signer.Address().Hex())
 
-	commonSetup, err := t.getCommonSetup(privateKey, settlementRPCUrl, l1RPCUrl)
+	commonSetup, err := t.getCommonSetup(settlementRPCUrl, l1RPCUrl, chains)
 	if err != nil {
 		return nil, err
 	}
@@ -72,10 +73,11 @@ func NewTransferToSettlement(
 		logger:      logger,
 		amount:      amount,
 		destAddress: destAddress,
-		privateKey:  privateKey,
+		signer:      t.signer,
 		srcClient: shared.NewETHClient(
 			logger.With("component", "l1_eth_client"),
 			commonSetup.l1Client,
+			commonSetup.l1Chain.Gas,
 		),
 		srcChainID:    commonSetup.l1ChainID,
 		srcTransactor: l1t,
@@ -83,6 +85,7 @@ func NewTransferToSettlement(
 		destClient: shared.NewETHClient(
 			logger.With("component", "settlement_eth_client"),
 			commonSetup.settlementClient,
+			commonSetup.settlementChain.Gas,
 		),
 		destFilterer: sf,
 		destChainID:  commonSetup.settlementChainID,
@@ -98,9 +101,12 @@ func NewTransferToL1(
 	l1RPCUrl string,
 	l1ContractAddr common.Address,
 	settlementContractAddr common.Address,
+	chains *shared.ChainRegistry,
 ) (*Transfer, error) {
-	t := &Transfer{logger: logger}
-	commonSetup, err := t.getCommonSetup(privateKey, settlementRPCUrl, l1RPCUrl)
+	t := &Transfer{logger: logger, signer: shared.NewLocalSigner(privateKey)}
+	t.logger.Info("signing address used for InitiateTransfer tx on source chain", "address", t.signer.Address().Hex())
+
+	commonSetup, err := t.getCommonSetup(settlementRPCUrl, l1RPCUrl, chains)
 	if err != nil {
 		return nil, err
 	}
@@ -122,10 +128,11 @@ func NewTransferToL1(
 		logger:      logger,
 		amount:      amount,
 		destAddress: destAddress,
-		privateKey:  privateKey,
+		signer:      t.signer,
 		srcClient: shared.NewETHClient(
 			logger.With("component", "settlement_eth_client"),
 			commonSetup.settlementClient,
+			commonSetup.settlementChain.Gas,
 		),
 		srcChainID:    commonSetup.settlementChainID,
 		srcTransactor: st,
@@ -133,6 +140,7 @@ func NewTransferToL1(
 		destClient: shared.NewETHClient(
 			logger.With("component", "l1_eth_client"),
 			commonSetup.l1Client,
+			commonSetup.l1Chain.Gas,
 		),
 		destFilterer: l1f,
 		destChainID:  commonSetup.l1ChainID,
@@ -142,23 +150,17 @@ func NewTransferToL1(
 type commonSetup struct {
 	l1Client          *ethclient.Client
 	l1ChainID         *big.Int
+	l1Chain           shared.ChainConfig
 	settlementClient  *ethclient.Client
 	settlementChainID *big.Int
+	settlementChain   shared.ChainConfig
 }
 
 func (t *Transfer) getCommonSetup(
-	privateKey *ecdsa.PrivateKey,
 	settlementRPCUrl string,
 	l1RPCUrl string,
+	chains *shared.ChainRegistry,
 ) (*commonSetup, error) {
-	pubKey := &privateKey.PublicKey
-	pubKeyBytes := crypto.FromECDSAPub(pubKey)
-	hash := sha3.NewLegacyKeccak256()
-	hash.Write(pubKeyBytes[1:])
-	address := hash.Sum(nil)[12:]
-	valAddr := common.BytesToAddress(address)
-	t.logger.Info("signing address used for InitiateTransfer tx on source chain", "address", valAddr.Hex())
-
 	l1Client, err := ethclient.Dial(l1RPCUrl)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial l1 rpc: %s", err)
@@ -168,6 +170,10 @@ func (t *Transfer) getCommonSetup(
 		return nil, fmt.Errorf("failed to get l1 chain id: %s", err)
 	}
 	t.logger.Debug("L1 chain id", "chain_id", l1ChainID)
+	l1Chain, err := chains.LookupRole(l1ChainID, shared.L1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid l1 chain: %s", err)
+	}
 
 	settlementClient, err := ethclient.Dial(settlementRPCUrl)
 	if err != nil {
@@ -178,25 +184,29 @@ func (t *Transfer) getCommonSetup(
 		return nil, fmt.Errorf("failed to get settlement chain id: %s", err)
 	}
 	t.logger.Debug("settlement chain id", "chain_id", settlementChainID)
+	settlementChain, err := chains.LookupRole(settlementChainID, shared.Settlement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid settlement chain: %s", err)
+	}
 
 	return &commonSetup{
 		l1Client:          l1Client,
 		l1ChainID:         l1ChainID,
+		l1Chain:           l1Chain,
 		settlementClient:  settlementClient,
 		settlementChainID: settlementChainID,
+		settlementChain:   settlementChain,
 	}, nil
 }
 
 func (t *Transfer) Start(ctx context.Context) error {
 
-	opts, err := t.srcClient.CreateTransactOpts(ctx, t.privateKey, t.srcChainID)
+	opts, err := t.srcClient.CreateTransactOpts(ctx, t.signer, t.srcChainID, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to get transact opts: %s", err)
 	}
 
-	// Important: tx value must match amount in transfer!
-	// TODO: Look into being able to observe error logs from failed transactions that're still included in a block.
-	// This method of calling InitiateTransfer silently failed when tx.value != amount.
+	// Important: tx value must match amount in transfer, InitiateTransfer reverts otherwise.
 	opts.Value = t.amount
 
 	// Store block num on dest BEFORE initiating transfer
@@ -205,15 +215,21 @@ func (t *Transfer) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to get dest block number before initiating transfer: %s", err)
 	}
 
+	buildInitiateTransfer := func(
+		ctx context.Context,
+		opts *bind.TransactOpts,
+	) (*gethtypes.Transaction, error) {
+		return t.srcTransactor.InitiateTransfer(opts, t.destAddress, t.amount)
+	}
+	if _, err := t.srcClient.EstimateGasLimit(ctx, opts, buildInitiateTransfer, false); err != nil {
+		return fmt.Errorf("failed to estimate gas limit: %s", err)
+	}
+
 	submitInitiateTransfer := func(
 		ctx context.Context,
 		opts *bind.TransactOpts,
 	) (*gethtypes.Transaction, error) {
-		tx, err := t.srcTransactor.InitiateTransfer(
-			opts,
-			t.destAddress,
-			t.amount,
-		)
+		tx, err := buildInitiateTransfer(ctx, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initiate transfer: %s", err)
 		}
@@ -227,16 +243,26 @@ func (t *Transfer) Start(ctx context.Context) error {
 		return tx, nil
 	}
 
-	receipt, err := t.srcClient.WaitMinedWithRetry(ctx, opts, submitInitiateTransfer)
+	mined, err := t.srcClient.WaitMinedWithRetry(ctx, opts, submitInitiateTransfer)
+	var revertErr *shared.RevertError
+	if errors.As(err, &revertErr) {
+		return fmt.Errorf("initiate transfer tx %s reverted: %s", revertErr.Receipt.TxHash.Hex(), revertErr.Reason)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to wait for initiate transfer tx to be mined: %s", err)
 	}
+	receipt := mined.Receipt
 
 	includedInBlock := receipt.BlockNumber.Uint64()
 	if includedInBlock == math.MaxUint64 {
 		return fmt.Errorf("transfer initiation tx not included in block")
 	}
-	t.logger.Info("initiateTransfer tx included in block", "block_number", includedInBlock)
+	t.logger.Info(
+		"initiateTransfer tx included in block",
+		"block_number", includedInBlock,
+		"tx_hash", receipt.TxHash.Hex(),
+		"attempt", mined.Attempt.Attempt,
+	)
 
 	// Obtain event on src chain, transfer idx needed for dest chain
 	event, err := t.srcFilterer.ObtainTransferInitiatedBySender(&bind.FilterOpts{